@@ -4,11 +4,13 @@ import (
 	"boughtnine/life"
 	"embed"
 	"image/color"
+	"time"
 )
 
 type BallEntity struct {
-	Shape *life.Shape
-	World *life.World
+	Shape  *life.Shape
+	World  *life.World
+	Sparks *life.ParticleEmitter
 
 	Animation *life.Animation
 	States    map[string]*life.Animation
@@ -52,11 +54,53 @@ func NewBallEntity(world *life.World, assets embed.FS) *BallEntity {
 		States: make(map[string]*life.Animation),
 	}
 
+	ballEntity.Sparks = world.AddEmitter(life.NewParticleEmitter(&life.ParticleEmitterProps{
+		Target:           ball,
+		Lifetime:         400 * time.Millisecond,
+		LifetimeVariance: 150 * time.Millisecond,
+		Direction:        -90,
+		Spread:           160,
+		Speed:            180,
+		SpeedVariance:    80,
+		Gravity:          life.NewVector2(0, 600),
+		StartColor:       color.RGBA{R: 255, G: 220, B: 120, A: 255},
+		EndColor:         color.RGBA{R: 255, G: 80, A: 0},
+		StartScale:       1,
+		EndScale:         0.3,
+		Size:             3,
+		Blend:            life.BlendAdditive,
+		ZIndex:           1001,
+	}))
+
+	if flash, err := life.NewHitFlashEffect(color.White); err == nil {
+		ball.Effect = flash
+	}
+
+	ball.AddComponent(&ballEntity)
+	world.AddSystem(newBallSystem())
+
 	ballEntity.Initialize()
 
 	return &ballEntity
 }
 
+// newBallSystem returns a system that rolls and slows down every shape that
+// has a BallEntity. The world keeps state in the systems added to it, so
+// each world gets its own.
+func newBallSystem() *life.System {
+	return &life.System{
+		Name:     "ball",
+		Order:    life.SystemOrderGameplay,
+		Requires: []life.Component{(*BallEntity)(nil)},
+		Update: func(world *life.World, shapes []*life.Shape, ld life.LoopData) {
+			for _, shape := range shapes {
+				ballEntity, _ := life.GetComponent[*BallEntity](shape)
+				ballEntity.Update(ld)
+			}
+		},
+	}
+}
+
 func (ballEntity *BallEntity) Initialize() {
 	ball := ballEntity.Shape
 
@@ -68,6 +112,15 @@ func (ballEntity *BallEntity) Initialize() {
 		}
 
 		ballEntity.World.PlaySoundWithVolume("ball_hit", volume)
+
+		if volume > 0.3 {
+			ballEntity.Sparks.Burst(int(volume * 12))
+
+			ball.SetUniform("Amount", 1.0)
+			ballEntity.World.After(80*time.Millisecond, func() {
+				ball.SetUniform("Amount", 0.0)
+			})
+		}
 	}
 }
 
@@ -3,6 +3,7 @@ package entities
 import (
 	"boughtnine/life"
 	"embed"
+	"image/color"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -12,8 +13,9 @@ type PlayerEntity struct {
 	Shape *life.Shape
 	World *life.World
 
-	Animation *life.Animation
-	States    map[string]*life.Animation
+	Controller *life.Controller
+	Animator   *life.Animator
+	Dust       *life.ParticleEmitter
 }
 
 const (
@@ -45,19 +47,55 @@ func NewPlayerEntity(world *life.World, assets embed.FS) *PlayerEntity {
 	player := life.NewShape(defaultProps)
 	world.Register(player)
 
-	playerEntity := PlayerEntity{
-		Shape:  player,
-		World:  world,
-		States: make(map[string]*life.Animation),
+	controller := &life.Controller{
+		Left:      ebiten.KeyA,
+		Right:     ebiten.KeyD,
+		Jump:      ebiten.KeySpace,
+		Speed:     playerSpeed * 100 / 60,
+		JumpForce: playerSpeed * 80 / 60,
+		JumpTag:   "ground",
+		OnJump: func(*life.Shape) {
+			world.PlaySound("jump")
+		},
 	}
 
-	animationWalk := life.NewAnimation(player, 100*time.Millisecond, true, sprites[13:16]...)
-	animationIdle := life.NewAnimation(player, 100*time.Millisecond, true, sprites[13:16]...)
+	animator := life.NewAnimator(map[string]*life.Animation{
+		"walk": life.NewAnimation(player, 100*time.Millisecond, true, sprites[13:16]...),
+		"idle": life.NewAnimation(player, 100*time.Millisecond, true, sprites[13:16]...),
+	}, "walk")
+	animator.Choose = func(*life.Shape) string {
+		if controller.Moving {
+			return "walk"
+		}
+		return "idle"
+	}
 
-	playerEntity.States["walk"] = animationWalk
-	playerEntity.States["idle"] = animationIdle
+	player.AddComponent(controller, animator)
+
+	dust := world.AddEmitter(life.NewParticleEmitter(&life.ParticleEmitterProps{
+		Target:           player,
+		Offset:           life.NewVector2(0, player.Height/2),
+		Lifetime:         500 * time.Millisecond,
+		LifetimeVariance: 200 * time.Millisecond,
+		Direction:        -90,
+		Spread:           150,
+		Speed:            40,
+		SpeedVariance:    20,
+		Damping:          3,
+		StartColor:       color.RGBA{R: 200, G: 190, B: 170, A: 200},
+		EndColor:         color.RGBA{R: 200, G: 190, B: 170, A: 0},
+		StartScale:       1,
+		EndScale:         2,
+		Size:             3,
+	}))
 
-	playerEntity.Animation = playerEntity.States["walk"]
+	playerEntity := PlayerEntity{
+		Shape:      player,
+		World:      world,
+		Controller: controller,
+		Animator:   animator,
+		Dust:       dust,
+	}
 
 	playerEntity.Initialize()
 
@@ -65,20 +103,7 @@ func NewPlayerEntity(world *life.World, assets embed.FS) *PlayerEntity {
 }
 
 func (playerEntity *PlayerEntity) SetAnimation(name string) {
-	if animation, exists := playerEntity.States[name]; exists {
-
-		if playerEntity.Animation != nil && playerEntity.Animation.IsPlaying() {
-			playerEntity.Animation.Stop()
-		}
-
-		playerEntity.Animation = animation
-
-		if !animation.IsPlaying() {
-			animation.Start()
-		}
-	} else {
-		playerEntity.Animation = nil
-	}
+	playerEntity.Animator.Play(name)
 }
 
 func (playerEntity *PlayerEntity) Initialize() {
@@ -89,32 +114,10 @@ func (playerEntity *PlayerEntity) Initialize() {
 		player.Flip.X = *direction.X == life.DirectionLeft
 	})
 
-}
-
-func isCollidingWithTag(playerEntity *PlayerEntity, tag string) bool {
-	objects := playerEntity.World.GetElementsByTagName(tag)
-	for _, element := range objects {
-		if playerEntity.Shape.IsCollidingWith(element) {
-			return true
+	player.OnCollisionFunc = func(who *life.Shape) {
+		if who.Tag == "ground" {
+			playerEntity.Dust.Burst(8)
 		}
 	}
-	return false
-}
-
-func (playerEntity *PlayerEntity) Update(ld life.LoopData) {
-
-	player := playerEntity.Shape
-
-	if playerEntity.World.IsKeyPressed(ebiten.KeyA) {
-		player.SetXVelocity(-playerSpeed * 100 * ld.Delta)
-	} else if playerEntity.World.IsKeyPressed(ebiten.KeyD) {
-		player.SetXVelocity(playerSpeed * 100 * ld.Delta)
-	} else {
-		playerEntity.SetAnimation("idle")
-	}
 
-	if playerEntity.World.IsKeyPressed(ebiten.KeySpace) && isCollidingWithTag(playerEntity, "ground") {
-		player.Jump(playerSpeed * 80 * ld.Delta)
-		playerEntity.World.PlaySound("jump")
-	}
 }
@@ -15,6 +15,8 @@ func NewWorld() *life.World {
 		HasLimits:     false,
 		AirResistance: 1,
 		Title:         "Basketball Game",
+		Transition:    life.NewFadeTransition(nil),
+		MergeTiles:    life.TileMergeChains,
 	})
 
 	world.CreateBorders()
@@ -20,6 +20,9 @@ require (
 	golang.org/x/exp v0.0.0-20190731235908-ec7cb31e5a56
 	golang.org/x/image v0.28.0
 	golang.org/x/mobile v0.0.0-20210208171126-f462b3930c8f
-	golang.org/x/sync v0.8.0
+	golang.org/x/sync v0.15.0
 	golang.org/x/sys v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/text v0.26.0 // indirect
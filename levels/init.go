@@ -1,23 +1,115 @@
 package levels
 
-import "boughtnine/life"
+import (
+	"boughtnine/life"
+	"image"
+	"image/color"
+	"log"
 
-func LoadResources() {
-	world.LoadSound("jump", assets, "assets/sounds/jump.wav")
-	world.LoadSound("level_complete", assets, "assets/sounds/tada.mp3")
-	world.LoadSound("ball_hit", assets, "assets/sounds/ballhit.mp3")
-	world.LoadMusic("background", assets, "assets/sounds/background.mp3")
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func LoadResources(loader life.AssetLoader) error {
+	sounds := map[string]string{
+		"jump":           "assets/sounds/jump.wav",
+		"level_complete": "assets/sounds/tada.mp3",
+		"ball_hit":       "assets/sounds/ballhit.mp3",
+	}
+	for name, path := range sounds {
+		if err := loader.LoadSound(name, assets, path); err != nil {
+			return err
+		}
+	}
+
+	if err := loader.LoadMusic("background", assets, "assets/sounds/background.mp3"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var graphicsLoaded bool
+
+// loadGraphics loads the images and fonts the levels draw with. It touches
+// the GPU, so it runs from Init on the main goroutine rather than from
+// Preload, and only once.
+func loadGraphics() {
+	if graphicsLoaded {
+		return
+	}
+	graphicsLoaded = true
 
 	imageBack, err := life.LoadImageFromFS(assets, "assets/background.png")
 	if err != nil {
-		panic(err)
+		log.Print(err)
 	}
-
 	background = imageBack
 
-	imageFloor, err := life.LoadImageFromFS(assets, "assets/floor.png")
-	if err != nil {
-		panic(err)
+	for name, data := range map[string][]byte{
+		"default":             goregular.TTF,
+		"default-bold":        gobold.TTF,
+		"default-italic":      goitalic.TTF,
+		"default-bold-italic": gobolditalic.TTF,
+	} {
+		font, err := life.ParseFont(data)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		life.RegisterFont(name, font)
+	}
+
+	life.RegisterIcon("key_e", keyIcon("E"))
+}
+
+// spawnSquare returns a map item spawning the named prefab as a square as
+// tall as the map's cells.
+func spawnSquare(name string) func(position life.Vector2, width float64, height float64) {
+	return func(position life.Vector2, width float64, height float64) {
+		world.Spawn(name, position, map[string]interface{}{
+			"Width":  height,
+			"Height": height,
+		})
 	}
-	floor = imageFloor
+}
+
+// crtPostProcess gives a level the look of an old screen. Passes that fail
+// to compile are left out.
+func crtPostProcess() *life.PostProcess {
+	chain := life.NewPostProcess()
+	for _, newPass := range []func() (*life.PostPass, error){
+		life.NewCRTPass,
+		func() (*life.PostPass, error) { return life.NewChromaticAberrationPass(1.5) },
+		func() (*life.PostPass, error) { return life.NewVignettePass(0.6) },
+	} {
+		pass, err := newPass()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		chain.Add(pass)
+	}
+	return chain
+}
+
+// keyIcon draws a keyboard key with label on it, for prompts.
+func keyIcon(label string) *ebiten.Image {
+	icon := ebiten.NewImage(16, 16)
+	icon.Fill(color.RGBA{R: 200, G: 200, B: 200, A: 255})
+	icon.SubImage(image.Rect(1, 1, 15, 14)).(*ebiten.Image).Fill(color.RGBA{R: 40, G: 40, B: 48, A: 255})
+
+	life.DrawText(icon, &life.TextProps{
+		Text:          label,
+		Type:          "default-bold",
+		Size:          11,
+		Anchor:        life.AnchorCenter,
+		Align:         life.AlignCenter,
+		VerticalAlign: life.AlignMiddle,
+		Y:             -1,
+	})
+	return icon
 }
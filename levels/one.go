@@ -32,18 +32,25 @@ var (
 	attached bool = true
 	pressed  bool = false
 	launched bool = false
+	showName bool = true
 
-	background, floor *ebiten.Image
+	background *ebiten.Image
 
 	ld life.LoopData
 )
 
 var One life.Level = life.Level{
 
+	Preload: func(loader life.AssetLoader) error {
+		if err := LoadResources(loader); err != nil {
+			return err
+		}
+		return loader.LoadPrefabs(assets, "assets/prefabs.yaml")
+	},
+
 	Init: func(w *life.World) {
 		world = w
-
-		LoadResources()
+		loadGraphics()
 
 		playerEntity = entities.NewPlayerEntity(world, assets)
 		player = playerEntity.Shape
@@ -53,89 +60,39 @@ var One life.Level = life.Level{
 
 		player.NotCollideWith(ball)
 
+		showName = true
+		world.Every(500*time.Millisecond, func() {
+			showName = !showName
+		})
+
+		world.AddBackground(life.BackgroundLayer{
+			Image:  background,
+			Repeat: life.RepeatStretch,
+		})
+
 		world.PlayMusic("background")
 
 	},
 
-	Map: life.Map{
-		"#############################",
-		"#                           #",
-		"#                        $  #",
-		"#   @                    $PP#",
-		"''''''                      #",
-		"#                           #",
-		"#                           #",
-		"#        !                  #",
-		"'''''''''''''''''''''''''''''",
-	},
+	MapFS:   assets,
+	MapFile: "assets/maps/one.txt",
 
 	MapItems: life.MapItems{
-		"#": func(position life.Vector2, width float64, height float64) {
-			s := life.NewShape(&life.ShapeProps{
-				Tag:          "wall",
-				Type:         life.ShapeRectangle,
-				Pattern:      life.PatternImage,
-				Physics:      false,
-				IsBody:       false,
-				Image:        floor,
-				X:            position.X,
-				Y:            position.Y,
-				Width:        height,
-				Height:       height,
-				Friction:     0.6,
-				Rebound:      0.3,
-				RotationLock: false,
-			})
-
-			world.Register(s)
-		},
-
-		"$": func(position life.Vector2, width float64, height float64) {
-			s := life.NewShape(&life.ShapeProps{
-				Name:         "wall",
-				Type:         life.ShapeRectangle,
-				Pattern:      life.PatternImage,
-				Physics:      false,
-				IsBody:       false,
-				Image:        floor,
-				X:            position.X,
-				Y:            position.Y,
-				Width:        width,
-				Height:       height,
-				Friction:     0,
-				Rebound:      0,
-				RotationLock: true,
-			})
-
-			world.Register(s)
-		},
-
-		"'": func(position life.Vector2, width float64, height float64) {
-			s := life.NewShape(&life.ShapeProps{
-				Tag:          "ground",
-				Type:         life.ShapeRectangle,
-				Pattern:      life.PatternImage,
-				Physics:      false,
-				IsBody:       false,
-				Image:        floor,
-				X:            position.X,
-				Y:            position.Y,
-				Width:        height,
-				Height:       height,
-				Friction:     0.5,
-				Rebound:      0,
-				RotationLock: true,
-			})
-
-			world.Register(s)
-		},
+		"wall":   spawnSquare("wall"),
+		"ground": spawnSquare("ground"),
 
 		"!": func(position life.Vector2, width float64, height float64) {
 			enemyEntity = entities.NewPlayerEntity(world, assets)
 			enemy = enemyEntity.Shape
 
-			enemy.SetX(position.X)
-			enemy.SetY(position.Y)
+			enemy.SetPosition(position.X, position.Y)
+
+			enemy.RemoveComponent((*life.Controller)(nil))
+			enemy.AddComponent(&life.AI{
+				Think: func(world *life.World, shape *life.Shape, ld life.LoopData) {
+					shape.Follow(player)
+				},
+			})
 
 		},
 
@@ -166,18 +123,13 @@ var One life.Level = life.Level{
 		},
 
 		"@": func(position life.Vector2, width float64, height float64) {
-			player.SetX(position.X)
-			player.SetY(position.Y)
+			player.SetPosition(position.X, position.Y)
 		},
 	},
 
 	Tick: func(ld_ life.LoopData) {
 		ld = ld_
-
-		playerEntity.Update(ld)
-		ballEntity.Update(ld)
-
-		enemy.Follow(player)
+		checkPause()
 
 		if pressed {
 			if player.Flip.X {
@@ -202,7 +154,7 @@ var One life.Level = life.Level{
 			}
 		}
 
-		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		if world.Mouse.IsLeftClicked {
 			if !pressed {
 
 				pressed = true
@@ -214,13 +166,13 @@ var One life.Level = life.Level{
 
 		toLaunch := !attached && !launched
 		if toLaunch {
-			x := world.Mouse.X - player.X
-			y := world.Mouse.Y - player.Y
+			x := world.Mouse.WorldX - player.X
+			y := world.Mouse.WorldY - player.Y
 			ball.SetVelocity(x*ball.Speed, y*ball.Speed)
 			launched = true
 		}
 
-		if ebiten.IsKeyPressed(ebiten.KeyE) && !attached {
+		if world.IsKeyPressed(ebiten.KeyE) && !attached {
 			// if ball is close, AABB collision
 			if ball.X+ball.Width > player.X && ball.X < player.X+player.Width &&
 				ball.Y+ball.Height > player.Y && ball.Y < player.Y+player.Height {
@@ -234,51 +186,64 @@ var One life.Level = life.Level{
 	},
 
 	Render: func(screen *ebiten.Image) {
-		cycle := 1.0
-		show := 0.5
-		if float64(time.Now().UnixNano()%int64(cycle*1e9))/1e9 < show {
+		if showName {
+			x, y := world.WorldToScreen(player.X, player.Y)
 			life.DrawText(screen, &life.TextProps{
-				Text:  player.Name,
-				X:     player.X - float64(len(player.Name))*1.48/2,
-				Y:     player.Y - 5,
-				Color: color.White,
+				Text:          player.Name,
+				X:             x + player.Width/2,
+				Y:             y - 5,
+				Color:         color.White,
+				Type:          "default-bold",
+				Size:          14,
+				Align:         life.AlignCenter,
+				VerticalAlign: life.AlignBottom,
+				OutlineColor:  color.Black,
+				OutlineWidth:  1,
 			})
 		}
 
-		life.DrawText(screen, &life.TextProps{
-			Text:  fmt.Sprint("FPS: ", int(1/ld.Delta)),
-			X:     0,
-			Y:     0,
-			Color: color.White,
-		})
-
-		world.Pen(life.ShapeRectangle, &life.ShapeProps{
-			X:       0,
-			Y:       0,
-			Width:   float64(world.Width),
-			Height:  float64(world.Height),
-			Pattern: life.PatternImage,
-			Image:   background,
-			ZIndex:  -1000,
-		})
+		if showFPS {
+			life.DrawText(screen, &life.TextProps{
+				Text:          fmt.Sprint("FPS: ", int(ebiten.ActualFPS())),
+				X:             4,
+				Y:             4,
+				Color:         color.White,
+				VerticalAlign: life.AlignTop,
+				ShadowColor:   color.RGBA{A: 160},
+				ShadowOffset:  life.NewVector2(1, 1),
+			})
+		}
 
 		if ball.X+ball.Width > player.X && ball.X < player.X+player.Width &&
 			ball.Y+ball.Height > player.Y && ball.Y < player.Y+player.Height &&
 			!attached {
+			x, y := world.WorldToScreen(ball.X, ball.Y)
 			life.DrawText(screen, &life.TextProps{
-				Text:  "Press E to pick up the ball",
-				X:     ball.X - float64(len("Press E to pick up the ball"))/2,
-				Y:     ball.Y - 5,
-				Color: color.White,
+				Text:          "Press [icon=key_e] to [color=#ff0][wave]pick up[/wave][/color] the ball",
+				Markup:        true,
+				Time:          world.GameTime(),
+				X:             x + ball.Width/2,
+				Y:             y - 5,
+				Color:         color.White,
+				Align:         life.AlignCenter,
+				VerticalAlign: life.AlignBottom,
+				MaxWidth:      120,
+				ShadowColor:   color.Black,
+				ShadowOffset:  life.NewVector2(1, 1),
 			})
 		}
 
 		if pressed && !launched {
-			world.Line(ball.X+ball.Width/2, ball.Y+ball.Height/2, world.Mouse.X, world.Mouse.Y, color.RGBA{R: 255}, 1.0)
+			world.Line(ball.X+ball.Width/2, ball.Y+ball.Height/2, world.Mouse.WorldX, world.Mouse.WorldY, color.RGBA{R: 255}, 1.0)
 		}
 	},
 
 	OnDestroy: func(world *life.World) {
 		world.StopMusic()
 	},
+
+	OnMapReload: func(world *life.World) {
+		enemy = nil
+		enemyEntity = nil
+	},
 }
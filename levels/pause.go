@@ -0,0 +1,127 @@
+package levels
+
+import (
+	"boughtnine/life"
+	"boughtnine/life/ui"
+	"image/color"
+	"strings"
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+var (
+	showFPS      bool = true
+	pauseKeyHeld bool
+)
+
+// checkPause opens the pause menu when Escape is pressed.
+func checkPause() {
+	held := world.IsKeyPressed(ebiten.KeyEscape)
+	if held && !pauseKeyHeld {
+		world.PushScene(newPauseScene())
+	}
+	pauseKeyHeld = held
+}
+
+func newPauseScene() *life.Scene {
+	var menu *ui.UI
+	scene := &life.Scene{Name: "pause", RenderBelow: true}
+
+	scene.Init = func(world *life.World) {
+		menu = ui.New(world, nil)
+		menu.OnCancel = func() {
+			world.RemoveScene(scene)
+		}
+
+		resume := ui.NewButton(&ui.ButtonProps{
+			Text:    "Resume",
+			OnClick: menu.OnCancel,
+		})
+
+		volume := ui.NewSlider(&ui.SliderProps{
+			ElementProps: ui.ElementProps{Flex: 1},
+			Value:        world.AudioManager.GetMusicVolume(),
+			Step:         0.05,
+			OnChange: func(value float64) {
+				world.AudioManager.SetMusicVolume(value)
+			},
+		})
+
+		name := ui.NewTextInput(&ui.TextInputProps{
+			ElementProps: ui.ElementProps{Flex: 1},
+			Placeholder:  "Player name",
+			MaxLength:    16,
+			Validate: func(text string) bool {
+				for _, r := range text {
+					if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' {
+						return false
+					}
+				}
+				return true
+			},
+			OnChange: func(text string) {
+				if player != nil && strings.TrimSpace(text) != "" {
+					player.SetName(text)
+				}
+			},
+			OnSubmit: func(text string) {
+				world.RemoveScene(scene)
+			},
+		})
+		if player != nil {
+			name.SetText(player.Name)
+		}
+
+		levelList := ui.NewList(&ui.ListProps{
+			Items:    []string{"Level one", "Level two"},
+			Selected: world.CurrentLevel,
+		})
+
+		menu.Add(ui.NewPanel(&ui.PanelProps{
+			ElementProps: ui.ElementProps{
+				Anchor:  life.AnchorCenter,
+				Width:   260,
+				Padding: ui.Pad(16),
+			},
+			Layout: ui.LayoutColumn,
+			Gap:    10,
+			Framed: true,
+			Children: []ui.Widget{
+				ui.NewLabel(&ui.LabelProps{Text: "Paused", FontSize: 22, Align: life.AlignCenter}),
+				resume,
+				ui.Row(8, ui.NewLabel(&ui.LabelProps{Text: "Name"}), name),
+				ui.Row(8, ui.NewLabel(&ui.LabelProps{Text: "Music"}), volume),
+				ui.NewCheckbox(&ui.CheckboxProps{
+					Text:    "Show FPS",
+					Checked: showFPS,
+					OnChange: func(checked bool) {
+						showFPS = checked
+					},
+				}),
+				levelList,
+				ui.NewButton(&ui.ButtonProps{
+					Text: "Play level",
+					OnClick: func() {
+						world.RemoveScene(scene)
+						world.SwitchToLevel(levelList.Selected)
+					},
+				}),
+			},
+		}))
+		menu.Focus(resume)
+	}
+
+	scene.Tick = func(ld life.LoopData) {
+		menu.Update()
+	}
+
+	scene.Render = func(screen *ebiten.Image) {
+		bounds := screen.Bounds()
+		vector.DrawFilledRect(screen, 0, 0, float32(bounds.Dx()), float32(bounds.Dy()), color.RGBA{A: 140}, false)
+		menu.Draw(screen)
+	}
+
+	return scene
+}
@@ -9,46 +9,6 @@ import (
 var Two life.Level = life.Level{
 
 	MapItems: life.MapItems{
-		"#": func(position life.Vector2, width float64, height float64) {
-			s := life.NewShape(&life.ShapeProps{
-				Name:         "wall",
-				Type:         life.ShapeRectangle,
-				Pattern:      life.PatternColor,
-				Physics:      false,
-				IsBody:       false,
-				Background:   color.Opaque,
-				X:            position.X,
-				Y:            position.Y,
-				Width:        width,
-				Height:       height,
-				Friction:     0.5,
-				Rebound:      0,
-				RotationLock: true,
-			})
-
-			world.Register(s)
-		},
-
-		"'": func(position life.Vector2, width float64, height float64) {
-			s := life.NewShape(&life.ShapeProps{
-				Tag:          "ground",
-				Type:         life.ShapeRectangle,
-				Pattern:      life.PatternColor,
-				Physics:      false,
-				IsBody:       false,
-				Background:   color.Opaque,
-				X:            position.X,
-				Y:            position.Y,
-				Width:        width,
-				Height:       height,
-				Friction:     0.5,
-				Rebound:      0,
-				RotationLock: true,
-			})
-
-			world.Register(s)
-		},
-
 		"F": func(position life.Vector2, width float64, height float64) {
 			s := life.NewShape(&life.ShapeProps{
 				Type:       life.ShapeRectangle,
@@ -69,31 +29,37 @@ var Two life.Level = life.Level{
 		},
 
 		"@": func(position life.Vector2, width float64, height float64) {
-			player.SetX(position.X)
-			player.SetY(position.Y)
+			player.SetPosition(position.X, position.Y)
 		},
 	},
 
 	Init: func(world_ *life.World) {
 		world = world_
+		loadGraphics()
+
+		world.DefinePrefab("solid", &life.ShapeProps{
+			Pattern:      life.PatternColor,
+			Background:   color.Opaque,
+			Friction:     0.5,
+			RotationLock: true,
+		})
+		world.DefinePrefab("block", &life.ShapeProps{
+			Name: "wall",
+		}).Extends("solid")
+		world.DefinePrefab("block-ground", &life.ShapeProps{
+			Tag: "ground",
+		}).Extends("solid")
 
 		playerEntity = entities.NewPlayerEntity(world, assets)
 		player = playerEntity.Shape
 	},
 
 	Tick: func(ld life.LoopData) {
-		playerEntity.Update(ld)
+		checkPause()
 	},
 
-	Map: life.Map{
-		"#############################",
-		"#      @                    #",
-		"#      '''                  #",
-		"#                           #",
-		"#  '''                      #",
-		"#          '''              #",
-		"#                           #",
-		"#             FFF           #",
-		"'''''''''''''''''''''''''''''",
-	},
+	MapFS:   assets,
+	MapFile: "assets/maps/two.txt",
+
+	PostProcess: crtPostProcess(),
 }
@@ -15,6 +15,7 @@ type Animation struct {
 	isPlaying    bool
 	ticker       *time.Ticker
 	stopCh       chan bool
+	timer        *Timer
 	onFinish     func(*Shape)
 }
 
@@ -30,58 +31,76 @@ func NewAnimation(target *Shape, speed time.Duration, loop bool, frames ...*ebit
 		speed:        speed,
 		loop:         loop,
 		isPlaying:    false,
-		stopCh:       make(chan bool),
 		onFinish:     func(*Shape) {},
 	}
 
 	return anim
 }
 
+// Start plays the animation. Animations of registered shapes advance on the
+// world's game clock, so they pause and slow down with it; others use a
+// wall-clock ticker.
 func (a *Animation) Start() *Animation {
-	if a.isPlaying {
+	if a.IsPlaying() {
 		return a
 	}
 
 	a.isPlaying = true
+
+	if w := a.target.world; w != nil {
+		a.timer = w.Every(a.speed, a.advance)
+		return a
+	}
+
 	a.ticker = time.NewTicker(a.speed)
+	a.stopCh = make(chan bool)
 
-	go func() {
+	go func(ticker *time.Ticker, stopCh chan bool) {
 		for {
 			select {
-			case <-a.ticker.C:
-				if !a.isPlaying {
-					continue
-				}
-
-				a.currentFrame++
-				if a.currentFrame >= len(a.frames) {
-					a.currentFrame = 0
-					if !a.loop {
-						a.isPlaying = false
-						a.onFinish(a.target)
-						return
-					}
-				}
-
-				if a.currentFrame < len(a.frames) {
-					a.target.Image = a.frames[a.currentFrame]
+			case <-ticker.C:
+				if a.isPlaying {
+					a.advance()
 				}
 
-			case <-a.stopCh:
+			case <-stopCh:
 				return
 			}
 		}
-	}()
+	}(a.ticker, a.stopCh)
 
 	return a
 }
 
+// advance shows the next frame, stopping at the end unless the animation
+// loops.
+func (a *Animation) advance() {
+	a.currentFrame++
+	if a.currentFrame >= len(a.frames) {
+		a.currentFrame = 0
+		if !a.loop {
+			a.Stop()
+			a.onFinish(a.target)
+			return
+		}
+	}
+
+	if a.currentFrame < len(a.frames) {
+		a.target.Image = a.frames[a.currentFrame]
+	}
+}
+
 func (a *Animation) Stop() *Animation {
 	a.isPlaying = false
+	if a.timer != nil {
+		a.timer.Cancel()
+		a.timer = nil
+	}
 	if a.ticker != nil {
 		a.ticker.Stop()
+		close(a.stopCh)
+		a.ticker = nil
 	}
-	a.stopCh <- true
 	return a
 }
 
@@ -91,5 +110,5 @@ func (a *Animation) OnFinish(callback func(*Shape)) *Animation {
 }
 
 func (a *Animation) IsPlaying() bool {
-	return a.isPlaying
+	return a.isPlaying && (a.timer == nil || a.timer.Active())
 }
@@ -1,10 +1,10 @@
 package life
 
 import (
-	"embed"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
+	"io/fs"
 	"os"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -25,8 +25,8 @@ func LoadImage(path string) (*ebiten.Image, error) {
 	return ebiten.NewImageFromImage(img), nil
 }
 
-func LoadImageFromFS(fs embed.FS, path string) (*ebiten.Image, error) {
-	file, err := fs.Open(path)
+func LoadImageFromFS(fsys fs.FS, path string) (*ebiten.Image, error) {
+	file, err := fsys.Open(path)
 	if err != nil {
 		return nil, err
 	}
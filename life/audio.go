@@ -54,6 +54,14 @@ type AudioProps struct {
 }
 
 func NewAudioManager(props *AudioProps) *AudioManager {
+	am := NewSilentAudioManager(props)
+	am.context = audio.NewContext(SampleRate)
+	return am
+}
+
+// NewSilentAudioManager creates a manager that loads and decodes audio but
+// never opens an audio device. Playback calls succeed without producing sound.
+func NewSilentAudioManager(props *AudioProps) *AudioManager {
 	if props == nil {
 		props = &AudioProps{
 			MasterVolume: 1.0,
@@ -63,7 +71,6 @@ func NewAudioManager(props *AudioProps) *AudioManager {
 	}
 
 	return &AudioManager{
-		context:      audio.NewContext(SampleRate),
 		sounds:       make(map[string]*Sound),
 		music:        make(map[string]*Music),
 		masterVolume: props.MasterVolume,
@@ -187,6 +194,10 @@ func (am *AudioManager) PlaySoundWithVolume(name string, volume float64) error {
 		return fmt.Errorf("sound %s has no data", name)
 	}
 
+	if am.context == nil {
+		return nil
+	}
+
 	sound.mutex.Lock()
 	defer sound.mutex.Unlock()
 
@@ -225,6 +236,10 @@ func (am *AudioManager) PlayMusicWithOptions(name string, loop bool, volume floa
 		return fmt.Errorf("music %s not found", name)
 	}
 
+	if am.context == nil {
+		return nil
+	}
+
 	if am.currentMusic != nil {
 		am.stopCurrentMusic()
 	}
@@ -0,0 +1,142 @@
+package life
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type RepeatMode string
+
+const (
+	RepeatNone    RepeatMode = "none"
+	RepeatX       RepeatMode = "repeat-x"
+	RepeatY       RepeatMode = "repeat-y"
+	RepeatBoth    RepeatMode = "repeat"
+	RepeatStretch RepeatMode = "stretch"
+)
+
+// BackgroundLayer is an image drawn behind every shape. ScrollFactor says how
+// much the layer follows the camera: 0 keeps it fixed to the screen, 1 moves
+// it with the world, values in between give parallax depth. Velocity scrolls
+// the layer by itself, in pixels per second.
+type BackgroundLayer struct {
+	Image        *ebiten.Image
+	ScrollFactor Vector2
+	Repeat       RepeatMode
+	Velocity     Vector2
+	Offset       Vector2
+
+	// Opacity is between 0 and 1, with zero meaning opaque like an unset
+	// ShapeProps.Opacity. Set Hidden to stop drawing the layer instead.
+	Opacity float64
+	Hidden  bool
+
+	scroll Vector2
+}
+
+// AddBackground adds a layer in front of the existing background layers. The
+// world's layers are reset from Level.Backgrounds whenever a level is mounted.
+func (w *World) AddBackground(layer BackgroundLayer) {
+	w.Backgrounds = append(w.Backgrounds, layer)
+}
+
+func (w *World) updateBackgrounds(delta float64) {
+	for i := range w.Backgrounds {
+		layer := &w.Backgrounds[i]
+		layer.scroll = layer.scroll.Add(layer.Velocity.Mul(delta))
+	}
+}
+
+func (w *World) drawBackgrounds(screen *ebiten.Image) {
+	if len(w.Backgrounds) == 0 {
+		return
+	}
+
+	screenWidth := float64(screen.Bounds().Dx())
+	screenHeight := float64(screen.Bounds().Dy())
+	centerX := float64(w.Width) / 2
+	centerY := float64(w.Height) / 2
+
+	cameraX, cameraY, zoom := centerX, centerY, 1.0
+	if w.Camera != nil {
+		cameraX, cameraY = w.Camera.renderPosition()
+		if w.Camera.Zoom > 0 {
+			zoom = w.Camera.Zoom
+		}
+	}
+
+	// Auto-scroll is interpolated like shapes so it stays smooth above the
+	// physics rate.
+	lead := w.interpolationAlpha() * w.FixedStep
+
+	for _, layer := range w.Backgrounds {
+		if layer.Image == nil || layer.Hidden {
+			continue
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.Filter = ebiten.FilterLinear
+		if layer.Opacity > 0 && layer.Opacity < 1 {
+			op.ColorScale.ScaleAlpha(float32(layer.Opacity))
+		}
+
+		imgWidth := float64(layer.Image.Bounds().Dx())
+		imgHeight := float64(layer.Image.Bounds().Dy())
+
+		if layer.Repeat == RepeatStretch {
+			op.GeoM.Scale(screenWidth/imgWidth, screenHeight/imgHeight)
+			screen.DrawImage(layer.Image, op)
+			continue
+		}
+
+		scaleX := 1 + (zoom-1)*layer.ScrollFactor.X
+		scaleY := 1 + (zoom-1)*layer.ScrollFactor.Y
+
+		scrollX := layer.scroll.X + layer.Velocity.X*lead
+		scrollY := layer.scroll.Y + layer.Velocity.Y*lead
+
+		originX := centerX + (layer.Offset.X+scrollX-layer.ScrollFactor.X*cameraX-(1-layer.ScrollFactor.X)*centerX)*scaleX
+		originY := centerY + (layer.Offset.Y+scrollY-layer.ScrollFactor.Y*cameraY-(1-layer.ScrollFactor.Y)*centerY)*scaleY
+
+		tileWidth := imgWidth * scaleX
+		tileHeight := imgHeight * scaleY
+
+		xs := []float64{originX}
+		if layer.Repeat == RepeatX || layer.Repeat == RepeatBoth {
+			xs = tilePositions(originX, tileWidth, screenWidth)
+		}
+		ys := []float64{originY}
+		if layer.Repeat == RepeatY || layer.Repeat == RepeatBoth {
+			ys = tilePositions(originY, tileHeight, screenHeight)
+		}
+
+		for _, y := range ys {
+			for _, x := range xs {
+				op.GeoM.Reset()
+				op.GeoM.Scale(scaleX, scaleY)
+				op.GeoM.Translate(x, y)
+				screen.DrawImage(layer.Image, op)
+			}
+		}
+	}
+}
+
+// tilePositions returns where to place tiles of the given size so that they
+// cover [0, length) and line up with origin.
+func tilePositions(origin, size, length float64) []float64 {
+	if size <= 0 {
+		return nil
+	}
+
+	start := math.Mod(origin, size)
+	if start > 0 {
+		start -= size
+	}
+
+	var positions []float64
+	for p := start; p < length; p += size {
+		positions = append(positions, p)
+	}
+	return positions
+}
@@ -0,0 +1,191 @@
+package life
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type Rect struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+func (r Rect) Contains(p Vector2) bool {
+	return p.X >= r.X && p.X <= r.X+r.Width && p.Y >= r.Y && p.Y <= r.Y+r.Height
+}
+
+// Camera maps world coordinates to the screen. X and Y are the world point
+// shown at the center of the screen.
+type Camera struct {
+	X, Y     float64
+	Zoom     float64
+	Rotation float64
+
+	// Target is followed every step. It may move freely inside DeadZone, a
+	// box in world pixels around the camera center, before the camera moves.
+	// Smoothing is how much of the remaining distance is kept each step: 0
+	// snaps to the target, values close to 1 trail behind it.
+	Target    *Shape
+	DeadZone  Vector2
+	Smoothing float64
+
+	// Bounds, when set, keeps the view inside this world rectangle.
+	Bounds *Rect
+
+	// Shake is driven by trauma in [0, 1], added with AddTrauma and drained
+	// by ShakeDecay per second. The actual offset grows with trauma squared.
+	MaxShakeOffset float64
+	MaxShakeAngle  float64
+	ShakeDecay     float64
+	trauma         float64
+	shake          struct{ X, Y, Angle float64 }
+
+	prevX, prevY float64
+
+	world *World
+}
+
+func NewCamera(world *World) *Camera {
+	x := float64(world.Width) / 2
+	y := float64(world.Height) / 2
+
+	return &Camera{
+		X:              x,
+		Y:              y,
+		Zoom:           1,
+		MaxShakeOffset: 12,
+		MaxShakeAngle:  3 * Deg,
+		ShakeDecay:     1.5,
+		prevX:          x,
+		prevY:          y,
+		world:          world,
+	}
+}
+
+func (c *Camera) Follow(target *Shape) {
+	c.Target = target
+}
+
+func (c *Camera) LookAt(x, y float64) {
+	c.X = x
+	c.Y = y
+	c.prevX = x
+	c.prevY = y
+	c.clampToBounds()
+}
+
+func (c *Camera) AddTrauma(amount float64) {
+	c.trauma = math.Min(1, math.Max(0, c.trauma+amount))
+}
+
+func (c *Camera) Trauma() float64 {
+	return c.trauma
+}
+
+func (c *Camera) update(delta float64) {
+	c.prevX = c.X
+	c.prevY = c.Y
+
+	if c.Target != nil {
+		targetX := c.Target.X + c.Target.Width/2
+		targetY := c.Target.Y + c.Target.Height/2
+
+		desiredX, desiredY := c.X, c.Y
+		halfDeadX := c.DeadZone.X / 2
+		halfDeadY := c.DeadZone.Y / 2
+
+		if targetX > c.X+halfDeadX {
+			desiredX = targetX - halfDeadX
+		} else if targetX < c.X-halfDeadX {
+			desiredX = targetX + halfDeadX
+		}
+		if targetY > c.Y+halfDeadY {
+			desiredY = targetY - halfDeadY
+		} else if targetY < c.Y-halfDeadY {
+			desiredY = targetY + halfDeadY
+		}
+
+		follow := 1 - c.Smoothing
+		c.X += (desiredX - c.X) * follow
+		c.Y += (desiredY - c.Y) * follow
+	}
+
+	c.clampToBounds()
+
+	if c.trauma > 0 {
+		c.trauma = math.Max(0, c.trauma-c.ShakeDecay*delta)
+	}
+
+	amount := c.trauma * c.trauma
+	if amount > 0 && c.world != nil && c.world.Rand != nil {
+		c.shake.X = c.MaxShakeOffset * amount * (c.world.Rand.Float64()*2 - 1)
+		c.shake.Y = c.MaxShakeOffset * amount * (c.world.Rand.Float64()*2 - 1)
+		c.shake.Angle = c.MaxShakeAngle * amount * (c.world.Rand.Float64()*2 - 1)
+	} else {
+		c.shake.X, c.shake.Y, c.shake.Angle = 0, 0, 0
+	}
+}
+
+func (c *Camera) viewSize() (float64, float64) {
+	zoom := c.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+	return float64(c.world.Width) / zoom, float64(c.world.Height) / zoom
+}
+
+func (c *Camera) clampToBounds() {
+	if c.Bounds == nil || c.world == nil {
+		return
+	}
+
+	viewWidth, viewHeight := c.viewSize()
+	c.X = clampCenter(c.X, c.Bounds.X, c.Bounds.Width, viewWidth)
+	c.Y = clampCenter(c.Y, c.Bounds.Y, c.Bounds.Height, viewHeight)
+}
+
+func clampCenter(center, min, size, view float64) float64 {
+	if view >= size {
+		return min + size/2
+	}
+	return math.Max(min+view/2, math.Min(min+size-view/2, center))
+}
+
+// VisibleRect is the world rectangle currently on screen, ignoring rotation.
+func (c *Camera) VisibleRect() Rect {
+	viewWidth, viewHeight := c.viewSize()
+	return Rect{
+		X:      c.X - viewWidth/2,
+		Y:      c.Y - viewHeight/2,
+		Width:  viewWidth,
+		Height: viewHeight,
+	}
+}
+
+func (c *Camera) geoM(x, y float64) ebiten.GeoM {
+	zoom := c.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+
+	var m ebiten.GeoM
+	m.Translate(-(x + c.shake.X), -(y + c.shake.Y))
+	m.Rotate(-(c.Rotation + c.shake.Angle))
+	m.Scale(zoom, zoom)
+	m.Translate(float64(c.world.Width)/2, float64(c.world.Height)/2)
+	return m
+}
+
+// View is the world-to-screen transform used for drawing this frame,
+// interpolated between physics steps like the shapes it draws.
+func (c *Camera) View() ebiten.GeoM {
+	x, y := c.renderPosition()
+	return c.geoM(x, y)
+}
+
+// renderPosition is the camera center interpolated between physics steps.
+func (c *Camera) renderPosition() (float64, float64) {
+	alpha := c.world.interpolationAlpha()
+	return c.prevX + (c.X-c.prevX)*alpha, c.prevY + (c.Y-c.prevY)*alpha
+}
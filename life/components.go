@@ -0,0 +1,199 @@
+package life
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Health is a shape's hit points. When Current drops to zero OnDeath is
+// called once, or the shape is unregistered if OnDeath is nil.
+type Health struct {
+	Current float64
+	Max     float64
+	OnDeath func(shape *Shape)
+
+	dead bool
+}
+
+func NewHealth(max float64) *Health {
+	return &Health{Current: max, Max: max}
+}
+
+func (h *Health) Damage(amount float64) {
+	h.Current -= amount
+	if h.Current < 0 {
+		h.Current = 0
+	}
+}
+
+func (h *Health) Heal(amount float64) {
+	h.Current += amount
+	if h.Max > 0 && h.Current > h.Max {
+		h.Current = h.Max
+	}
+	if h.Current > 0 {
+		h.dead = false
+	}
+}
+
+func (h *Health) IsDead() bool {
+	return h.Current <= 0
+}
+
+// Controller moves a shape from the keyboard. Speed is the horizontal
+// velocity, in pixels per second, and JumpForce the upward velocity of a
+// jump, which is only allowed while touching a shape tagged JumpTag, if set.
+type Controller struct {
+	Left, Right, Jump ebiten.Key
+	Speed             float64
+	JumpForce         float64
+	JumpTag           string
+	OnJump            func(shape *Shape)
+	Disabled          bool
+
+	// Moving is set by the controller system on every step.
+	Moving bool
+}
+
+// Animator switches a shape between named animations. When Choose is set,
+// the animator system plays the state it returns on every step.
+type Animator struct {
+	States  map[string]*Animation
+	Current string
+	Choose  func(shape *Shape) string
+}
+
+func NewAnimator(states map[string]*Animation, initial string) *Animator {
+	if states == nil {
+		states = make(map[string]*Animation)
+	}
+	return &Animator{States: states, Current: initial}
+}
+
+// Play stops the current animation and starts the named one. Playing the
+// state that is already playing does nothing.
+func (a *Animator) Play(name string) {
+	next, exists := a.States[name]
+	if current, ok := a.States[a.Current]; ok && current.IsPlaying() {
+		if name == a.Current {
+			return
+		}
+		current.Stop()
+	}
+
+	a.Current = name
+	if exists && !next.IsPlaying() {
+		next.Start()
+	}
+}
+
+// AI calls Think every Interval seconds of game time, or on every step if
+// Interval is zero.
+type AI struct {
+	Think    func(world *World, shape *Shape, ld LoopData)
+	Interval float64
+
+	elapsed float64
+}
+
+func (w *World) addBuiltinSystems() {
+	w.AddSystem(&System{
+		Name:     "controller",
+		Order:    SystemOrderInput,
+		Requires: []Component{(*Controller)(nil)},
+		Update:   updateControllers,
+	})
+	w.AddSystem(&System{
+		Name:     "ai",
+		Order:    SystemOrderAI,
+		Requires: []Component{(*AI)(nil)},
+		Update:   updateAIs,
+	})
+	w.AddSystem(&System{
+		Name:     "health",
+		Order:    SystemOrderGameplay,
+		Requires: []Component{(*Health)(nil)},
+		Update:   updateHealth,
+	})
+	w.AddSystem(&System{
+		Name:     "animator",
+		Order:    SystemOrderAnimation,
+		Requires: []Component{(*Animator)(nil)},
+		Update:   updateAnimators,
+	})
+}
+
+func updateControllers(w *World, shapes []*Shape, ld LoopData) {
+	for _, shape := range shapes {
+		c, _ := GetComponent[*Controller](shape)
+		if c.Disabled || shape.Body == nil {
+			c.Moving = false
+			continue
+		}
+
+		switch {
+		case w.IsKeyPressed(c.Left):
+			shape.SetXVelocity(-c.Speed)
+			c.Moving = true
+		case w.IsKeyPressed(c.Right):
+			shape.SetXVelocity(c.Speed)
+			c.Moving = true
+		default:
+			c.Moving = false
+		}
+
+		if c.JumpForce > 0 && w.IsKeyPressed(c.Jump) && (c.JumpTag == "" || w.isTouchingTag(shape, c.JumpTag)) {
+			shape.Jump(c.JumpForce)
+			if c.OnJump != nil {
+				c.OnJump(shape)
+			}
+		}
+	}
+}
+
+func (w *World) isTouchingTag(shape *Shape, tag string) bool {
+	for _, other := range w.GetElementsByTagName(tag) {
+		if shape.IsCollidingWith(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func updateAIs(w *World, shapes []*Shape, ld LoopData) {
+	for _, shape := range shapes {
+		ai, _ := GetComponent[*AI](shape)
+		if ai.Think == nil {
+			continue
+		}
+
+		ai.elapsed += ld.Delta * w.TimeScale
+		if ai.elapsed < ai.Interval {
+			continue
+		}
+		ai.elapsed = 0
+		ai.Think(w, shape, ld)
+	}
+}
+
+func updateHealth(w *World, shapes []*Shape, ld LoopData) {
+	for _, shape := range shapes {
+		h, _ := GetComponent[*Health](shape)
+		if !h.IsDead() || h.dead {
+			continue
+		}
+
+		h.dead = true
+		if h.OnDeath != nil {
+			h.OnDeath(shape)
+		} else {
+			w.Unregister(shape)
+		}
+	}
+}
+
+func updateAnimators(w *World, shapes []*Shape, ld LoopData) {
+	for _, shape := range shapes {
+		a, _ := GetComponent[*Animator](shape)
+		if a.Choose != nil {
+			a.Play(a.Choose(shape))
+		}
+	}
+}
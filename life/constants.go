@@ -3,6 +3,7 @@ package life
 import (
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -71,20 +72,35 @@ func ID() string {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
 	b := make([]byte, 7)
 	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+		b[i] = charset[randIntn(len(charset))]
 	}
 	return string(b)
 }
 
 func RandName() string {
 	names := []string{"James", "Robert", "John", "Michael", "David", "William", "Richard", "Thomas", "Charles", "Islam", "Mohammed", "Ramy"}
-	return names[rand.Intn(len(names))]
+	return names[randIntn(len(names))]
 }
 
 func Defined(v interface{}) bool {
 	return v != nil
 }
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+var (
+	rng      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rngMutex sync.Mutex
+)
+
+// SeedRandom reseeds the generator behind ID and RandName, so shapes created
+// after it get the same IDs and names on every run with the same seed.
+func SeedRandom(seed int64) {
+	rngMutex.Lock()
+	defer rngMutex.Unlock()
+	rng.Seed(seed)
+}
+
+func randIntn(n int) int {
+	rngMutex.Lock()
+	defer rngMutex.Unlock()
+	return rng.Intn(n)
 }
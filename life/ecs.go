@@ -0,0 +1,197 @@
+package life
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Component is data attached to a shape. A shape holds at most one component
+// of each type, so components are usually pointers to structs, which systems
+// can change in place.
+type Component interface{}
+
+var (
+	// namedComponentTypes maps the names of the component types ever added
+	// to them, so Restore can recreate components by name.
+	namedComponentTypes      = make(map[string]reflect.Type)
+	namedComponentTypesMutex sync.RWMutex
+)
+
+func componentType(name string) reflect.Type {
+	namedComponentTypesMutex.RLock()
+	defer namedComponentTypesMutex.RUnlock()
+	return namedComponentTypes[name]
+}
+
+// Orders of the built-in systems. Systems with a lower Order run first, and
+// systems with the same Order run in the order they were added.
+const (
+	SystemOrderInput     = 100
+	SystemOrderAI        = 200
+	SystemOrderGameplay  = 300
+	SystemOrderAnimation = 400
+)
+
+// System updates every registered shape that has all the Requires
+// components, once per fixed step. Requires lists components by example,
+// such as (*Health)(nil).
+//
+// Systems run after the physics step and the shapes' own updates, and before
+// the level's Tick. Adding a system with the name of an existing one replaces
+// it.
+type System struct {
+	Name     string
+	Order    int
+	Requires []Component
+	Update   func(world *World, shapes []*Shape, ld LoopData)
+
+	types []reflect.Type
+	added int
+}
+
+// AddComponent attaches components to the shape, replacing those of the same
+// type it already has.
+func (s *Shape) AddComponent(components ...Component) *Shape {
+	if s.components == nil {
+		s.components = make(map[reflect.Type]Component)
+	}
+	for _, c := range components {
+		if c != nil {
+			t := reflect.TypeOf(c)
+			s.components[t] = c
+
+			namedComponentTypesMutex.Lock()
+			namedComponentTypes[t.String()] = t
+			namedComponentTypesMutex.Unlock()
+		}
+	}
+	return s
+}
+
+// RemoveComponent detaches the shape's component of the same type as kind.
+func (s *Shape) RemoveComponent(kind Component) {
+	delete(s.components, reflect.TypeOf(kind))
+}
+
+// HasComponents reports whether the shape has a component of every type
+// given.
+func (s *Shape) HasComponents(kinds ...Component) bool {
+	for _, kind := range kinds {
+		if _, ok := s.components[reflect.TypeOf(kind)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// GetComponent returns the shape's component of type T.
+func GetComponent[T Component](s *Shape) (T, bool) {
+	c, ok := s.components[reflect.TypeOf((*T)(nil)).Elem()].(T)
+	return c, ok
+}
+
+func componentTypes(kinds []Component) []reflect.Type {
+	types := make([]reflect.Type, len(kinds))
+	for i, kind := range kinds {
+		types[i] = reflect.TypeOf(kind)
+	}
+	return types
+}
+
+func (s *Shape) hasComponentTypes(types []reflect.Type) bool {
+	for _, t := range types {
+		if _, ok := s.components[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Query returns the registered shapes that have a component of every type
+// given, in registration order.
+func (w *World) Query(kinds ...Component) []*Shape {
+	return w.queryComponents(componentTypes(kinds))
+}
+
+func (w *World) queryComponents(types []reflect.Type) []*Shape {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	var result []*Shape
+	for _, obj := range w.Objects {
+		if obj.hasComponentTypes(types) {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
+// AddSystem registers a system that runs for as long as the world does.
+// Systems that belong to a single level go in Level.Systems instead.
+func (w *World) AddSystem(system *System) {
+	w.addSystem(system)
+}
+
+func (w *World) addSystem(system *System) {
+	system.types = componentTypes(system.Requires)
+	w.systemCount++
+	system.added = w.systemCount
+
+	w.RemoveSystem(system.Name)
+	w.systems = append(w.systems, system)
+	sort.SliceStable(w.systems, func(i, j int) bool {
+		if w.systems[i].Order != w.systems[j].Order {
+			return w.systems[i].Order < w.systems[j].Order
+		}
+		return w.systems[i].added < w.systems[j].added
+	})
+}
+
+// RemoveSystem unregisters the system with the given name.
+func (w *World) RemoveSystem(name string) {
+	for i, system := range w.systems {
+		if system.Name == name {
+			w.systems = append(w.systems[:i], w.systems[i+1:]...)
+			return
+		}
+	}
+}
+
+// Systems returns the registered systems in the order they run.
+func (w *World) Systems() []*System {
+	return append([]*System(nil), w.systems...)
+}
+
+// runSystems runs every system once. Each system queries the world when its
+// turn comes, so it sees the shapes earlier systems added or removed.
+func (w *World) runSystems(ld LoopData) {
+	systems := append([]*System(nil), w.systems...)
+	for _, system := range systems {
+		if system.Update == nil {
+			continue
+		}
+
+		shapes := w.queryComponents(system.types)
+		if len(shapes) > 0 || len(system.types) == 0 {
+			system.Update(w, shapes, ld)
+		}
+	}
+}
+
+// mountSystems adds the level's systems, and unmountSystems removes them when
+// the level is unloaded.
+func (w *World) mountSystems(level Level) {
+	w.levelSystems = nil
+	for _, system := range level.Systems {
+		w.addSystem(system)
+		w.levelSystems = append(w.levelSystems, system.Name)
+	}
+}
+
+func (w *World) unmountSystems() {
+	for _, name := range w.levelSystems {
+		w.RemoveSystem(name)
+	}
+	w.levelSystems = nil
+}
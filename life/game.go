@@ -15,12 +15,24 @@ func NewGame(world *World) *Game {
 }
 
 func (g *Game) Update() error {
+	if input, ok := g.world.Input.(*EbitenInput); ok {
+		input.collectChars()
+	}
 	return g.world.Update()
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	g.world.Draw(screen)
-	g.world.Render(screen)
+	chain := g.world.activePostProcess()
+	chain.Draw(screen, g.world.GameTime().Seconds(), func(target *ebiten.Image) {
+		g.world.Draw(target)
+
+		if _, levelRenders := g.world.renderingScenes(); levelRenders && g.world.levelLoaded {
+			g.world.Render(target)
+		}
+
+		g.world.renderScenes(target)
+		g.world.drawLevelSwitch(target)
+	})
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
@@ -34,7 +46,7 @@ func (g *Game) Run() error {
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 
 	if g.world.Init != nil {
-		g.world.SelectLevel(0)
+		g.world.SwitchToLevel(g.world.CurrentLevel)
 	}
 
 	return ebiten.RunGame(g)
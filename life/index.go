@@ -0,0 +1,150 @@
+package life
+
+import (
+	"math"
+
+	"github.com/ByteArena/box2d"
+)
+
+// shapeIndex finds registered shapes by tag and by name without scanning
+// World.Objects. It is kept up to date by Register, Unregister and the
+// shape's SetTag and SetName.
+type shapeIndex struct {
+	byTag  map[string][]*Shape
+	byName map[string][]*Shape
+}
+
+func newShapeIndex() *shapeIndex {
+	return &shapeIndex{
+		byTag:  make(map[string][]*Shape),
+		byName: make(map[string][]*Shape),
+	}
+}
+
+func (i *shapeIndex) add(s *Shape) {
+	s.indexedTag = s.Tag
+	s.indexedName = s.Name
+	i.byTag[s.Tag] = append(i.byTag[s.Tag], s)
+	i.byName[s.Name] = append(i.byName[s.Name], s)
+}
+
+func (i *shapeIndex) remove(s *Shape) {
+	removeFromBucket(i.byTag, s.indexedTag, s)
+	removeFromBucket(i.byName, s.indexedName, s)
+}
+
+func (i *shapeIndex) reset(objects []*Shape) {
+	i.byTag = make(map[string][]*Shape)
+	i.byName = make(map[string][]*Shape)
+	for _, obj := range objects {
+		i.add(obj)
+	}
+}
+
+func removeFromBucket(buckets map[string][]*Shape, key string, s *Shape) {
+	bucket := buckets[key]
+	for j, obj := range bucket {
+		if obj == s {
+			bucket = append(bucket[:j], bucket[j+1:]...)
+			break
+		}
+	}
+
+	if len(bucket) == 0 {
+		delete(buckets, key)
+	} else {
+		buckets[key] = bucket
+	}
+}
+
+// shapeOf returns the shape a body was created for.
+func shapeOf(body *box2d.B2Body) *Shape {
+	if body == nil {
+		return nil
+	}
+
+	shape, _ := body.GetUserData().(*Shape)
+	return shape
+}
+
+// indexed reports whether the shape is registered, and so in its world's
+// index. Unregistered shapes and merged map tiles have no body.
+func (s *Shape) indexed() bool {
+	return s.world != nil && s.Body != nil
+}
+
+// SetTag changes the shape's tag and keeps the world's tag index in sync.
+// Assigning Tag directly after Register leaves the shape indexed under its
+// old tag.
+func (s *Shape) SetTag(tag string) {
+	if s.indexed() {
+		s.world.mutex.Lock()
+		defer s.world.mutex.Unlock()
+		s.world.index.remove(s)
+		s.Tag = tag
+		s.world.index.add(s)
+		return
+	}
+	s.Tag = tag
+}
+
+// SetName is SetTag for the shape's name.
+func (s *Shape) SetName(name string) {
+	if s.indexed() {
+		s.world.mutex.Lock()
+		defer s.world.mutex.Unlock()
+		s.world.index.remove(s)
+		s.Name = name
+		s.world.index.add(s)
+		return
+	}
+	s.Name = name
+}
+
+// Bounds returns the shape's axis-aligned bounding box in world pixels.
+func (s *Shape) Bounds() Rect {
+	return Rect{X: s.X, Y: s.Y, Width: s.Width, Height: s.Height}
+}
+
+func (r Rect) Intersects(other Rect) bool {
+	return r.X <= other.X+other.Width && other.X <= r.X+r.Width &&
+		r.Y <= other.Y+other.Height && other.Y <= r.Y+r.Height
+}
+
+func rectAABB(rect Rect) box2d.B2AABB {
+	aabb := box2d.MakeB2AABB()
+	aabb.LowerBound = box2d.MakeB2Vec2(PixelsToMeters(rect.X), PixelsToMeters(rect.Y))
+	aabb.UpperBound = box2d.MakeB2Vec2(PixelsToMeters(rect.X+rect.Width), PixelsToMeters(rect.Y+rect.Height))
+	return aabb
+}
+
+// queryRegion calls fn once for every registered shape whose fixtures'
+// bounding boxes overlap rect, using box2d's broad-phase tree instead of
+// looking at every shape. fn returns false to stop the query.
+func (w *World) queryRegion(rect Rect, fn func(shape *Shape) bool) {
+	seen := make(map[*Shape]bool)
+
+	w.PhysicsWorld.QueryAABB(func(fixture *box2d.B2Fixture) bool {
+		shape := shapeOf(fixture.GetBody())
+		if shape == nil || seen[shape] {
+			return true
+		}
+		seen[shape] = true
+		return fn(shape)
+	}, rectAABB(rect))
+}
+
+// isVisible reports whether any part of the shape can fall inside the
+// visible world rectangle, checking a bounding circle so rotation and scale
+// are covered.
+func (s *Shape) isVisible(visible Rect) bool {
+	x, y, _ := s.renderState()
+	radius := math.Hypot(s.Width, s.Height) / 2 * math.Max(s.Scale, 1)
+	if s.Border != nil {
+		radius += s.Border.Width
+	}
+
+	cx := x + s.Width/2
+	cy := y + s.Height/2
+	return visible.Intersects(Rect{X: cx - radius, Y: cy - radius, Width: radius * 2, Height: radius * 2})
+}
@@ -0,0 +1,95 @@
+package life
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// InputState is everything the world reads from the player in one step.
+type InputState struct {
+	MouseX, MouseY  float64
+	IsLeftClicked   bool
+	IsRightClicked  bool
+	IsMiddleClicked bool
+	Keys            []ebiten.Key `json:",omitempty"`
+
+	// Chars are the characters typed since the last poll.
+	Chars []rune `json:",omitempty"`
+}
+
+func (s InputState) Equal(other InputState) bool {
+	if s.MouseX != other.MouseX || s.MouseY != other.MouseY ||
+		s.IsLeftClicked != other.IsLeftClicked ||
+		s.IsRightClicked != other.IsRightClicked ||
+		s.IsMiddleClicked != other.IsMiddleClicked ||
+		len(s.Keys) != len(other.Keys) ||
+		string(s.Chars) != string(other.Chars) {
+		return false
+	}
+
+	for i, key := range s.Keys {
+		if other.Keys[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
+// InputSource is polled by the world once per physics step.
+type InputSource interface {
+	Poll() InputState
+}
+
+// EbitenInput reads the real mouse and keyboard through ebiten. Characters
+// typed during an ebiten update are kept until the next Poll, so none are
+// lost or repeated when an update runs no step or several.
+type EbitenInput struct {
+	chars []rune
+
+	// idle counts the updates since the last Poll.
+	idle int
+}
+
+// collectChars adds the characters typed during this ebiten update. Those
+// no step polled by the end of the next update are dropped, so they do not
+// pile up while the world is paused.
+func (e *EbitenInput) collectChars() {
+	if e.idle > 1 {
+		e.chars = e.chars[:0]
+	}
+	e.idle++
+	e.chars = ebiten.AppendInputChars(e.chars)
+}
+
+func (e *EbitenInput) Poll() InputState {
+	x, y := ebiten.CursorPosition()
+
+	state := InputState{
+		MouseX:          float64(x),
+		MouseY:          float64(y),
+		IsLeftClicked:   ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft),
+		IsRightClicked:  ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight),
+		IsMiddleClicked: ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle),
+	}
+
+	for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
+		if ebiten.IsKeyPressed(key) {
+			state.Keys = append(state.Keys, key)
+		}
+	}
+
+	e.idle = 0
+	if len(e.chars) > 0 {
+		state.Chars = e.chars
+		e.chars = nil
+	}
+
+	return state
+}
+
+// ManualInput returns whatever State was last set on it. It is handy for
+// driving headless worlds from tests.
+type ManualInput struct {
+	State InputState
+}
+
+func (m *ManualInput) Poll() InputState {
+	return m.State
+}
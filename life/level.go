@@ -1,17 +1,55 @@
 package life
 
-import "github.com/hajimehoshi/ebiten/v2"
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
 
 type Map []string
 type MapItems map[string]func(position Vector2, width float64, height float64)
 
+// AssetLoader is the part of the world a level's Preload can use. Its methods
+// lock what they change, so they are safe off the main goroutine while the
+// world keeps updating and drawing.
+type AssetLoader interface {
+	LoadSound(name string, fs embed.FS, filePath string) error
+	LoadMusic(name string, fs embed.FS, filePath string) error
+	LoadPrefabs(fsys fs.FS, filePath string) error
+}
+
 type Level struct {
-	Map      Map
-	MapItems MapItems
+	Map         Map
+	MapItems    MapItems
+	Backgrounds []BackgroundLayer
+
+	// MapFile, when set, is loaded from MapFS and used instead of Map. Files
+	// ending in .tmx or .tmj are Tiled maps, whose objects are passed to
+	// TiledItems by type.
+	MapFS      fs.FS
+	MapFile    string
+	TiledItems TiledItems
 
+	// Systems run while the level is mounted, alongside the world's own.
+	Systems []*System
+
+	// PostProcess, when set, replaces the world's PostProcess while the
+	// level is mounted.
+	PostProcess *PostProcess
+
+	// Preload loads the level's assets before Init. When the level is reached
+	// through NextLevel or SwitchToLevel it runs in the background while the
+	// world's LoadingScreen is drawn, so it only gets the world's loaders.
+	Preload   func(loader AssetLoader) error
 	Init      func(world *World)
 	Tick      func(ld LoopData)
 	Render    func(screen *ebiten.Image)
 	OnMount   func()
 	OnDestroy func(world *World)
+
+	// OnMapReload is called when World.WatchMaps reloads the level's map,
+	// after the old map's shapes are removed and before the new map is
+	// generated, so the level can drop its references to them.
+	OnMapReload func(world *World)
 }
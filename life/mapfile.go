@@ -0,0 +1,264 @@
+package life
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+// MapFile is a level layout loaded from disk. Legend optionally names the
+// characters of the map, so MapItems can be keyed by name ("wall") as well as
+// by character ("#"). Names with no item spawn the prefab of that name.
+type MapFile struct {
+	Map    Map
+	Legend map[string]string
+}
+
+// LoadMapFile reads a map from fsys. JSON files hold {"Map": [...],
+// "Legend": {...}}. Any other file is plain text: one row per line,
+// optionally followed by a "---" line and legend lines such as "# = wall".
+func LoadMapFile(fsys fs.FS, filePath string) (*MapFile, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map file %s: %w", filePath, err)
+	}
+
+	mapFile, err := parseMapFile(data, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse map file %s: %w", filePath, err)
+	}
+	return mapFile, nil
+}
+
+func parseMapFile(data []byte, filePath string) (*MapFile, error) {
+	if strings.EqualFold(path.Ext(filePath), ".json") {
+		var mapFile MapFile
+		if err := json.Unmarshal(data, &mapFile); err != nil {
+			return nil, err
+		}
+		return &mapFile, nil
+	}
+
+	return ParseMap(string(data))
+}
+
+// ParseMap parses the plain text map format described in LoadMapFile.
+func ParseMap(text string) (*MapFile, error) {
+	mapFile := &MapFile{
+		Legend: make(map[string]string),
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	inLegend := false
+
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "---" {
+			inLegend = true
+			continue
+		}
+
+		if !inLegend {
+			mapFile.Map = append(mapFile.Map, line)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		char, name, ok := strings.Cut(line, "=")
+		char = strings.TrimSpace(char)
+		name = strings.TrimSpace(name)
+		if !ok || len([]rune(char)) != 1 || name == "" {
+			return nil, fmt.Errorf("line %d: expected a legend entry like \"# = wall\", got %q", i+1, line)
+		}
+		mapFile.Legend[char] = name
+	}
+
+	for len(mapFile.Map) > 0 && strings.TrimSpace(mapFile.Map[len(mapFile.Map)-1]) == "" {
+		mapFile.Map = mapFile.Map[:len(mapFile.Map)-1]
+	}
+
+	if len(mapFile.Map) == 0 {
+		return nil, fmt.Errorf("map has no rows")
+	}
+	return mapFile, nil
+}
+
+// Items returns items keyed by the characters of the map, looking each
+// character up through the legend first and falling back to the character
+// itself.
+func (m *MapFile) Items(items MapItems) MapItems {
+	result := make(MapItems, len(items))
+	for key, fn := range items {
+		result[key] = fn
+	}
+
+	for char, name := range m.Legend {
+		if fn, ok := items[name]; ok {
+			result[char] = fn
+		}
+	}
+	return result
+}
+
+func (w *World) levelMapFS(level Level) fs.FS {
+	if w.mapWatch != nil {
+		return w.mapWatch.fsys
+	}
+	return level.MapFS
+}
+
+// loadedMap is a level's map file once read: either a MapFile or a Tiled
+// map, plus the raw bytes used to notice changes.
+type loadedMap struct {
+	file  *MapFile
+	tiled *TiledMap
+	data  []byte
+}
+
+func parseLevelMap(fsys fs.FS, filePath string, data []byte) (*loadedMap, error) {
+	loaded := &loadedMap{data: data}
+
+	var err error
+	if isTiledFile(filePath) {
+		loaded.tiled, err = parseTiledMap(fsys, filePath, data)
+	} else {
+		loaded.file, err = parseMapFile(data, filePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse map file %s: %w", filePath, err)
+	}
+	return loaded, nil
+}
+
+// loadLevelMap reads the level's MapFile, if it has one.
+func (w *World) loadLevelMap(level Level) (*loadedMap, error) {
+	if level.MapFile == "" {
+		return nil, nil
+	}
+
+	fsys := w.levelMapFS(level)
+	if fsys == nil {
+		return nil, fmt.Errorf("level map %s has no MapFS to load from", level.MapFile)
+	}
+
+	data, err := fs.ReadFile(fsys, level.MapFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map file %s: %w", level.MapFile, err)
+	}
+
+	return parseLevelMap(fsys, level.MapFile, data)
+}
+
+func (w *World) generateLoadedMap(level Level, loaded *loadedMap) {
+	if loaded.tiled != nil {
+		w.GenerateLevelFromTiled(loaded.tiled, level.TiledItems)
+		return
+	}
+	w.GenerateLevelFromMap(loaded.file.Map, w.prefabItems(loaded.file.Legend, loaded.file.Items(level.MapItems)))
+}
+
+type mapWatch struct {
+	fsys     fs.FS
+	interval time.Duration
+	lastPoll time.Time
+}
+
+// WatchMaps turns on hot reloading for development. Level map files are read
+// from fsys instead of Level.MapFS, and the current level's file (for Tiled
+// maps, the map itself rather than its tilesets) is polled at
+// the given interval. When it changes, the shapes built from the old map are
+// removed, along with their components, emitters and tweens, and the new map
+// is generated in place without re-running Init. The level's OnMapReload is
+// called in between to reset what it kept of the old map.
+func (w *World) WatchMaps(fsys fs.FS, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	w.mapWatch = &mapWatch{
+		fsys:     fsys,
+		interval: interval,
+	}
+}
+
+func (w *World) pollMapFile() {
+	watch := w.mapWatch
+	if watch == nil || !w.levelLoaded || w.levelSwitch != nil {
+		return
+	}
+
+	if time.Since(watch.lastPoll) < watch.interval {
+		return
+	}
+	watch.lastPoll = time.Now()
+
+	level := w.Levels[w.CurrentLevel]
+	if level.MapFile == "" {
+		return
+	}
+
+	data, err := fs.ReadFile(watch.fsys, level.MapFile)
+	if err != nil || (w.loadedMap != nil && bytes.Equal(data, w.loadedMap.data)) {
+		return
+	}
+
+	loaded, err := parseLevelMap(watch.fsys, level.MapFile, data)
+	if err != nil {
+		// Keep the running layout while the file is mid-edit.
+		log.Printf("life: not reloading %s: %v", level.MapFile, err)
+		if w.loadedMap != nil {
+			w.loadedMap.data = data
+		}
+		return
+	}
+
+	w.reloadMap(level, loaded)
+}
+
+func (w *World) reloadMap(level Level, loaded *loadedMap) {
+	removed := make(map[*Shape]bool, len(w.mapShapes))
+	for _, obj := range w.mapShapes {
+		removed[obj] = true
+		w.Unregister(obj)
+		obj.Body = nil
+		for _, c := range obj.components {
+			obj.RemoveComponent(c)
+		}
+	}
+	w.mapShapes = nil
+	w.mapTiles = nil
+	w.TileLayers = nil
+
+	// Emitters and tweens of the old map's shapes go with them.
+	emitters := w.emitters[:0]
+	for _, e := range w.emitters {
+		if !removed[e.Target] {
+			emitters = append(emitters, e)
+		}
+	}
+	w.emitters = emitters
+
+	tweens := w.tweens[:0]
+	for _, t := range w.tweens {
+		if removed[t.shape] {
+			t.active = false
+		} else {
+			tweens = append(tweens, t)
+		}
+	}
+	w.tweens = tweens
+
+	if level.OnMapReload != nil {
+		level.OnMapReload(w)
+	}
+
+	w.loadedMap = loaded
+	w.generateLoadedMap(level, loaded)
+}
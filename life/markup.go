@@ -0,0 +1,186 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TextEffect moves the characters of a run of text on their own.
+type TextEffect string
+
+const (
+	TextWave  TextEffect = "wave"
+	TextShake TextEffect = "shake"
+)
+
+// textSpan is a run of text, or a single icon, drawn in one style.
+type textSpan struct {
+	text   string
+	icon   *ebiten.Image
+	color  color.Color
+	bold   bool
+	italic bool
+	effect TextEffect
+}
+
+var (
+	icons      = make(map[string]*ebiten.Image)
+	iconsMutex sync.RWMutex
+)
+
+// RegisterIcon makes img available to markup as [icon=name]. Icons are
+// scaled to the height of the text they sit in.
+func RegisterIcon(name string, img *ebiten.Image) {
+	iconsMutex.Lock()
+	defer iconsMutex.Unlock()
+	icons[name] = img
+}
+
+func GetIcon(name string) *ebiten.Image {
+	iconsMutex.RLock()
+	defer iconsMutex.RUnlock()
+	return icons[name]
+}
+
+// parseMarkup splits text into spans by its tags:
+//
+//	[color=#ff0]...[/color]  text color, as for ParseHexColor
+//	[b]...[/b] [i]...[/i]    bold and italic
+//	[wave]...[/wave]         characters bobbing up and down
+//	[shake]...[/shake]       characters jittering in place
+//	[icon=name]              an icon registered with RegisterIcon
+//
+// "[[" is a literal "[". Tags that are not understood, including unknown
+// icons, are drawn as text. Closing tags end the innermost matching tag.
+func parseMarkup(text string) []textSpan {
+	var (
+		spans   []textSpan
+		current strings.Builder
+		colors  []color.Color
+		effects []TextEffect
+		bold    int
+		italic  int
+	)
+
+	style := func() textSpan {
+		span := textSpan{bold: bold > 0, italic: italic > 0}
+		if len(colors) > 0 {
+			span.color = colors[len(colors)-1]
+		}
+		if len(effects) > 0 {
+			span.effect = effects[len(effects)-1]
+		}
+		return span
+	}
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		span := style()
+		span.text = current.String()
+		spans = append(spans, span)
+		current.Reset()
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != '[' {
+			current.WriteByte(text[i])
+			continue
+		}
+		if strings.HasPrefix(text[i:], "[[") {
+			current.WriteByte('[')
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(text[i:], ']')
+		if end < 0 {
+			current.WriteString(text[i:])
+			break
+		}
+		tag := text[i+1 : i+end]
+		name, value, _ := strings.Cut(tag, "=")
+
+		handled := true
+		switch strings.ToLower(name) {
+		case "color":
+			c, err := ParseHexColor(value)
+			if err != nil {
+				handled = false
+				break
+			}
+			flush()
+			colors = append(colors, c)
+		case "/color":
+			flush()
+			if len(colors) > 0 {
+				colors = colors[:len(colors)-1]
+			}
+		case "b":
+			flush()
+			bold++
+		case "/b":
+			flush()
+			bold = max(bold-1, 0)
+		case "i":
+			flush()
+			italic++
+		case "/i":
+			flush()
+			italic = max(italic-1, 0)
+		case "wave", "shake":
+			flush()
+			effects = append(effects, TextEffect(strings.ToLower(name)))
+		case "/wave", "/shake":
+			flush()
+			if len(effects) > 0 {
+				effects = effects[:len(effects)-1]
+			}
+		case "icon":
+			img := GetIcon(value)
+			if img == nil {
+				handled = false
+				break
+			}
+			flush()
+			span := style()
+			span.icon = img
+			spans = append(spans, span)
+		default:
+			handled = false
+		}
+
+		if !handled {
+			current.WriteString(text[i : i+end+1])
+		}
+		i += end
+	}
+	flush()
+
+	return spans
+}
+
+// effectOffset returns how far the effect moves the character at index, the
+// character's position in the text, at t seconds.
+func effectOffset(effect TextEffect, index int, t, lineHeight float64) (float64, float64) {
+	switch effect {
+	case TextWave:
+		return 0, -math.Sin(t*6+float64(index)*0.5) * lineHeight * 0.15
+	case TextShake:
+		// A new jitter twenty times a second, the same for the shadow and
+		// outline copies drawn in the same frame.
+		step := int(t * 20)
+		noise := func(seed int) float64 {
+			n := uint32(index*374761393 + step*668265263 + seed*2147483647)
+			n = (n ^ (n >> 13)) * 1274126177
+			return float64(n^(n>>16))/math.MaxUint32*2 - 1
+		}
+		amount := math.Max(1, lineHeight*0.08)
+		return noise(1) * amount, noise(2) * amount
+	}
+	return 0, 0
+}
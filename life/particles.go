@@ -0,0 +1,296 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// BlendMode says how particles combine with what is under them.
+type BlendMode string
+
+const (
+	BlendAlpha    BlendMode = "alpha"
+	BlendAdditive BlendMode = "additive"
+)
+
+// ParticleEmitterProps configures a ParticleEmitter. Angles are in degrees,
+// with 0 pointing right and 90 down, speeds in pixels per second and
+// Gravity in pixels per second squared.
+type ParticleEmitterProps struct {
+	// Rate is how many particles are emitted per second while emitting.
+	// Bursts are emitted with Burst whatever the rate.
+	Rate         float64
+	MaxParticles int
+	Emitting     bool
+
+	Lifetime         time.Duration
+	LifetimeVariance time.Duration
+
+	Direction     float64
+	Spread        float64
+	Speed         float64
+	SpeedVariance float64
+	Gravity       Vector2
+	Damping       float64
+
+	// Colors and scales are interpolated over each particle's life. Alpha
+	// comes from the colors.
+	StartColor, EndColor color.Color
+	StartScale, EndScale float64
+
+	// Size is the side, in pixels, of the square drawn for particles without
+	// an Image. Images are drawn at their own size times the scale.
+	Size  float64
+	Image *ebiten.Image
+	Blend BlendMode
+
+	// Target makes the emitter follow a shape, emitting from its center plus
+	// Offset. Without a Target particles come from Position.
+	Target   *Shape
+	Offset   Vector2
+	Position Vector2
+	ZIndex   int
+}
+
+type particle struct {
+	position Vector2
+	velocity Vector2
+	age      float64
+	lifetime float64
+}
+
+// ParticleEmitter spawns and draws lightweight particles. Particles are not
+// shapes and have no bodies, so thousands of them are cheap; they do not
+// collide with anything.
+type ParticleEmitter struct {
+	ParticleEmitterProps
+
+	world       *World
+	particles   []particle
+	accumulator float64
+}
+
+// particlePixel is the white pixel scaled and tinted for particles without
+// an image.
+var particlePixel *ebiten.Image
+
+func NewParticleEmitter(props *ParticleEmitterProps) *ParticleEmitter {
+	if props == nil {
+		props = &ParticleEmitterProps{}
+	}
+	if props.MaxParticles <= 0 {
+		props.MaxParticles = 1000
+	}
+	if props.Lifetime <= 0 {
+		props.Lifetime = time.Second
+	}
+	if props.StartColor == nil {
+		props.StartColor = color.White
+	}
+	if props.EndColor == nil {
+		props.EndColor = props.StartColor
+	}
+	if props.StartScale == 0 {
+		props.StartScale = 1
+	}
+	if props.EndScale == 0 {
+		props.EndScale = props.StartScale
+	}
+	if props.Size == 0 {
+		props.Size = 4
+	}
+	if props.Blend == "" {
+		props.Blend = BlendAlpha
+	}
+
+	return &ParticleEmitter{ParticleEmitterProps: *props}
+}
+
+// AddEmitter makes the world update and draw the emitter. Emitters are
+// removed when the level is unloaded, and emitters following a shape are
+// removed once the shape is unregistered and their last particle is gone.
+func (w *World) AddEmitter(emitter *ParticleEmitter) *ParticleEmitter {
+	emitter.world = w
+	w.emitters = append(w.emitters, emitter)
+	return emitter
+}
+
+func (w *World) RemoveEmitter(emitter *ParticleEmitter) {
+	for i, e := range w.emitters {
+		if e == emitter {
+			w.emitters = append(w.emitters[:i], w.emitters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (e *ParticleEmitter) Start() {
+	e.Emitting = true
+}
+
+func (e *ParticleEmitter) Stop() {
+	e.Emitting = false
+	e.accumulator = 0
+}
+
+// Burst emits count particles at once.
+func (e *ParticleEmitter) Burst(count int) {
+	origin := e.origin()
+	for i := 0; i < count; i++ {
+		e.emit(origin)
+	}
+}
+
+// Count returns how many particles are alive.
+func (e *ParticleEmitter) Count() int {
+	return len(e.particles)
+}
+
+func (e *ParticleEmitter) origin() Vector2 {
+	if e.Target != nil {
+		return shapeCenter(e.Target).Add(e.Offset)
+	}
+	return e.Position
+}
+
+// random returns a number between -1 and 1, from the world's seeded
+// generator so replays emit the same particles.
+func (e *ParticleEmitter) random() float64 {
+	if e.world != nil && e.world.Rand != nil {
+		return e.world.Rand.Float64()*2 - 1
+	}
+	return 0
+}
+
+func (e *ParticleEmitter) emit(origin Vector2) {
+	if len(e.particles) >= e.MaxParticles {
+		return
+	}
+
+	angle := (e.Direction + e.random()*e.Spread/2) * Deg
+	speed := e.Speed + e.random()*e.SpeedVariance
+	lifetime := (e.Lifetime + time.Duration(e.random()*float64(e.LifetimeVariance))).Seconds()
+
+	e.particles = append(e.particles, particle{
+		position: origin,
+		velocity: Vector2{X: math.Cos(angle) * speed, Y: math.Sin(angle) * speed},
+		lifetime: math.Max(lifetime, 0.001),
+	})
+}
+
+// update ages and moves the particles by delta seconds, then emits new ones
+// at the emitter's rate.
+func (e *ParticleEmitter) update(delta float64) {
+	damping := math.Max(0, 1-e.Damping*delta)
+
+	alive := e.particles[:0]
+	for _, p := range e.particles {
+		p.age += delta
+		if p.age >= p.lifetime {
+			continue
+		}
+
+		p.velocity = p.velocity.Add(e.Gravity.Mul(delta)).Mul(damping)
+		p.position = p.position.Add(p.velocity.Mul(delta))
+		alive = append(alive, p)
+	}
+	e.particles = alive
+
+	if !e.Emitting || e.Rate <= 0 {
+		return
+	}
+
+	e.accumulator += delta * e.Rate
+	origin := e.origin()
+	for e.accumulator >= 1 {
+		e.accumulator--
+		e.emit(origin)
+	}
+}
+
+func lerpColor(a, b color.NRGBA, t float64) (float32, float32, float32, float32) {
+	mix := func(x, y uint8) float32 {
+		return float32(lerp(float64(x), float64(y), t) / 255)
+	}
+	return mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)
+}
+
+// Draw draws the particles in world space through view.
+func (e *ParticleEmitter) Draw(screen *ebiten.Image, view ebiten.GeoM) {
+	if len(e.particles) == 0 {
+		return
+	}
+
+	img := e.Image
+	baseScale := 1.0
+	if img == nil {
+		if particlePixel == nil {
+			particlePixel = ebiten.NewImage(1, 1)
+			particlePixel.Fill(color.White)
+		}
+		img = particlePixel
+		baseScale = e.Size
+	}
+	width := float64(img.Bounds().Dx())
+	height := float64(img.Bounds().Dy())
+
+	start := color.NRGBAModel.Convert(e.StartColor).(color.NRGBA)
+	end := color.NRGBAModel.Convert(e.EndColor).(color.NRGBA)
+
+	op := &ebiten.DrawImageOptions{}
+	if e.Blend == BlendAdditive {
+		op.Blend = ebiten.BlendLighter
+	}
+
+	for _, p := range e.particles {
+		t := p.age / p.lifetime
+		scale := baseScale * lerp(e.StartScale, e.EndScale, t)
+
+		op.GeoM.Reset()
+		op.GeoM.Translate(-width/2, -height/2)
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(p.position.X, p.position.Y)
+		op.GeoM.Concat(view)
+
+		r, g, b, a := lerpColor(start, end, t)
+		op.ColorScale.Reset()
+		op.ColorScale.Scale(r*a, g*a, b*a, a)
+
+		screen.DrawImage(img, op)
+	}
+}
+
+// updateEmitters advances every emitter by delta seconds of game time and
+// drops those whose shape is gone and whose particles have died.
+func (w *World) updateEmitters(delta float64) {
+	emitters := w.emitters[:0]
+	for _, e := range w.emitters {
+		if e.Target != nil && e.Target.Body == nil {
+			e.Emitting = false
+		}
+
+		e.update(delta)
+
+		if e.Target == nil || e.Target.Body != nil || len(e.particles) > 0 {
+			emitters = append(emitters, e)
+		}
+	}
+	for i := len(emitters); i < len(w.emitters); i++ {
+		w.emitters[i] = nil
+	}
+	w.emitters = emitters
+}
+
+// sortedEmitters returns the emitters ordered by ZIndex, keeping the order
+// they were added in between emitters with the same ZIndex.
+func (w *World) sortedEmitters() []*ParticleEmitter {
+	emitters := append([]*ParticleEmitter(nil), w.emitters...)
+	sort.SliceStable(emitters, func(i, j int) bool {
+		return emitters[i].ZIndex < emitters[j].ZIndex
+	})
+	return emitters
+}
@@ -0,0 +1,293 @@
+package life
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// PostPass is one full-screen pass of a PostProcess chain. It draws src,
+// the frame so far, into dst either through Effect or, for passes that need
+// more than one shader, through Apply.
+type PostPass struct {
+	Name    string
+	Enabled bool
+	Effect  *Effect
+	Apply   func(dst, src *ebiten.Image, time float64) error
+
+	err error
+}
+
+// PostProcess is an ordered chain of full-screen passes. The frame is drawn
+// to an offscreen image and every enabled pass reads the previous pass's
+// output, the last one drawing to the screen.
+type PostProcess struct {
+	Passes []*PostPass
+
+	buffers [2]*ebiten.Image
+}
+
+func NewPostProcess(passes ...*PostPass) *PostProcess {
+	return &PostProcess{Passes: passes}
+}
+
+// Add appends passes to the end of the chain.
+func (p *PostProcess) Add(passes ...*PostPass) *PostProcess {
+	p.Passes = append(p.Passes, passes...)
+	return p
+}
+
+// Pass returns the first pass with the given name, or nil.
+func (p *PostProcess) Pass(name string) *PostPass {
+	for _, pass := range p.Passes {
+		if pass.Name == name {
+			return pass
+		}
+	}
+	return nil
+}
+
+// SetEnabled turns the named pass on or off.
+func (p *PostProcess) SetEnabled(name string, enabled bool) {
+	if pass := p.Pass(name); pass != nil {
+		pass.Enabled = enabled
+	}
+}
+
+// Toggle flips the named pass on or off.
+func (p *PostProcess) Toggle(name string) {
+	if pass := p.Pass(name); pass != nil {
+		pass.Enabled = !pass.Enabled
+	}
+}
+
+// Err returns the error that stopped the pass from drawing. Failed passes
+// are skipped.
+func (pass *PostPass) Err() error {
+	if pass.err != nil {
+		return pass.err
+	}
+	if pass.Effect != nil {
+		return pass.Effect.Err()
+	}
+	return nil
+}
+
+func (p *PostProcess) enabledPasses() []*PostPass {
+	if p == nil {
+		return nil
+	}
+
+	var passes []*PostPass
+	for _, pass := range p.Passes {
+		if pass.Enabled && pass.Err() == nil && (pass.Effect != nil || pass.Apply != nil) {
+			passes = append(passes, pass)
+		}
+	}
+	return passes
+}
+
+func offscreenFor(img *ebiten.Image, size image.Point) *ebiten.Image {
+	if img == nil || img.Bounds().Size() != size {
+		return ebiten.NewImage(size.X, size.Y)
+	}
+	img.Clear()
+	return img
+}
+
+// Draw draws the frame that draw renders through the chain's enabled passes
+// onto screen. With no enabled pass draw renders straight to screen.
+func (p *PostProcess) Draw(screen *ebiten.Image, time float64, draw func(target *ebiten.Image)) {
+	passes := p.enabledPasses()
+	if len(passes) == 0 {
+		draw(screen)
+		return
+	}
+
+	size := screen.Bounds().Size()
+	p.buffers[0] = offscreenFor(p.buffers[0], size)
+	draw(p.buffers[0])
+
+	src := p.buffers[0]
+	for i, pass := range passes {
+		var dst *ebiten.Image
+		if i == len(passes)-1 {
+			dst = screen
+		} else {
+			p.buffers[(i+1)%2] = offscreenFor(p.buffers[(i+1)%2], size)
+			dst = p.buffers[(i+1)%2]
+		}
+
+		if err := pass.draw(dst, src, time); err != nil {
+			// The frame goes on without the failed pass.
+			dst.DrawImage(src, nil)
+		}
+		src = dst
+	}
+}
+
+func (pass *PostPass) draw(dst, src *ebiten.Image, time float64) (err error) {
+	if pass.Apply == nil {
+		return pass.Effect.draw(dst, src, ebiten.GeoM{}, ebiten.ColorScale{}, time)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to draw post-processing pass %s: %v", pass.Name, r)
+		}
+		if err != nil && pass.err == nil {
+			pass.err = err
+			log.Print(err)
+		}
+	}()
+	return pass.Apply(dst, src, time)
+}
+
+// activePostProcess returns the mounted level's chain, or the world's.
+func (w *World) activePostProcess() *PostProcess {
+	if w.levelLoaded && w.CurrentLevel < len(w.Levels) {
+		if chain := w.Levels[w.CurrentLevel].PostProcess; chain != nil {
+			return chain
+		}
+	}
+	return w.PostProcess
+}
+
+func builtinPass(name, shader string, uniforms map[string]interface{}) (*PostPass, error) {
+	effect, err := builtinEffect(shader, uniforms)
+	if err != nil {
+		return nil, err
+	}
+	return &PostPass{Name: name, Enabled: true, Effect: effect}, nil
+}
+
+// NewVignettePass darkens the corners of the screen by strength, from 0 to
+// 1. Its uniforms are "Strength", "Radius" and "Softness".
+func NewVignettePass(strength float64) (*PostPass, error) {
+	return builtinPass("vignette", "vignette", map[string]interface{}{
+		"Strength": strength,
+		"Radius":   0.9,
+		"Softness": 0.5,
+	})
+}
+
+// NewCRTPass bends the screen like an old tube and adds scanlines. Its
+// uniforms are "Curvature" and "Scanlines".
+func NewCRTPass() (*PostPass, error) {
+	return builtinPass("crt", "crt", map[string]interface{}{
+		"Curvature": 0.04,
+		"Scanlines": 0.2,
+	})
+}
+
+// NewChromaticAberrationPass splits the red and blue channels by up to
+// amount pixels at the edges of the screen. Its uniform is "Amount".
+func NewChromaticAberrationPass(amount float64) (*PostPass, error) {
+	return builtinPass("chromatic-aberration", "chromatic", map[string]interface{}{
+		"Amount": amount,
+	})
+}
+
+// NewPixelatePass draws the screen in blocks of size pixels. Its uniform is
+// "Size".
+func NewPixelatePass(size float64) (*PostPass, error) {
+	return builtinPass("pixelate", "pixelate", map[string]interface{}{
+		"Size": size,
+	})
+}
+
+// NewLUTPass grades colors through a lookup table image holding size slices
+// of size by size pixels side by side, such as a 256x16 strip for a size of
+// 16. The table must fit within the screen. Its uniform is "Strength".
+func NewLUTPass(lut *ebiten.Image) (*PostPass, error) {
+	bounds := lut.Bounds()
+	size := bounds.Dy()
+	if size < 2 || bounds.Dx() != size*size {
+		return nil, fmt.Errorf("invalid color lookup table: %dx%d is not n*n by n pixels", bounds.Dx(), bounds.Dy())
+	}
+
+	effect, err := builtinEffect("lut", map[string]interface{}{
+		"LUTSize":  float64(size),
+		"Strength": 1.0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Shader source images must all be the same size, so the table is
+	// copied into the corner of a screen-sized image.
+	var table *ebiten.Image
+	pass := &PostPass{Name: "lut", Enabled: true, Effect: effect}
+	pass.Apply = func(dst, src *ebiten.Image, time float64) error {
+		srcSize := src.Bounds().Size()
+		if srcSize.X < bounds.Dx() || srcSize.Y < bounds.Dy() {
+			return fmt.Errorf("color lookup table is larger than the screen")
+		}
+		if table == nil || table.Bounds().Size() != srcSize {
+			table = ebiten.NewImage(srcSize.X, srcSize.Y)
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(-float64(bounds.Min.X), -float64(bounds.Min.Y))
+			table.DrawImage(lut, op)
+		}
+
+		op := &ebiten.DrawRectShaderOptions{Uniforms: effect.uniforms(time)}
+		op.Images[0] = src
+		op.Images[1] = table
+		dst.DrawRectShader(srcSize.X, srcSize.Y, effect.shader, op)
+		return nil
+	}
+	return pass, nil
+}
+
+// NewBloomPass makes pixels brighter than threshold, from 0 to 1, bleed
+// light around them, scaled by intensity. The blur runs at half resolution.
+// Its Effect holds the bright pass's "Threshold" uniform.
+func NewBloomPass(threshold, intensity float64) (*PostPass, error) {
+	bright, err := builtinEffect("brightpass", map[string]interface{}{"Threshold": threshold})
+	if err != nil {
+		return nil, err
+	}
+	blur, err := builtinEffect("blur", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var full, half, halfBlur *ebiten.Image
+	pass := &PostPass{Name: "bloom", Enabled: true, Effect: bright}
+	pass.Apply = func(dst, src *ebiten.Image, time float64) error {
+		size := src.Bounds().Size()
+		halfSize := image.Pt(max(size.X/2, 1), max(size.Y/2, 1))
+		full = offscreenFor(full, size)
+		half = offscreenFor(half, halfSize)
+		halfBlur = offscreenFor(halfBlur, halfSize)
+
+		if err := bright.draw(full, src, ebiten.GeoM{}, ebiten.ColorScale{}, time); err != nil {
+			return err
+		}
+
+		op := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+		op.GeoM.Scale(float64(halfSize.X)/float64(size.X), float64(halfSize.Y)/float64(size.Y))
+		half.DrawImage(full, op)
+
+		blur.Set("Direction", []float32{1, 0})
+		if err := blur.draw(halfBlur, half, ebiten.GeoM{}, ebiten.ColorScale{}, time); err != nil {
+			return err
+		}
+		half.Clear()
+		blur.Set("Direction", []float32{0, 1})
+		if err := blur.draw(half, halfBlur, ebiten.GeoM{}, ebiten.ColorScale{}, time); err != nil {
+			return err
+		}
+
+		dst.DrawImage(src, nil)
+
+		op = &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear, Blend: ebiten.BlendLighter}
+		op.GeoM.Scale(float64(size.X)/float64(halfSize.X), float64(size.Y)/float64(halfSize.Y))
+		op.ColorScale.Scale(float32(intensity), float32(intensity), float32(intensity), float32(intensity))
+		dst.DrawImage(half, op)
+		return nil
+	}
+	return pass, nil
+}
@@ -0,0 +1,238 @@
+package life
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Prefab is a named shape configuration that World.Spawn copies. A prefab
+// that extends another starts from its base: fields set in Props and
+// Properties replace the base's, and its Components are added after the
+// base's.
+type Prefab struct {
+	Name string
+	Base string
+
+	// Props holds the prefab's shape fields. Zero fields are inherited from
+	// the base, so a zero value that must override one goes in Properties.
+	Props *ShapeProps
+
+	// Properties are applied after Props, like Shape.SetProps: keys naming a
+	// ShapeProps field set it and the rest become custom properties.
+	Properties map[string]interface{}
+
+	// Components are copied onto every spawned shape. Pointers to structs
+	// are copied shallowly, so each shape gets its own component.
+	Components []Component
+}
+
+// Extends makes the prefab inherit from base.
+func (p *Prefab) Extends(base string) *Prefab {
+	p.Base = base
+	return p
+}
+
+// DefinePrefab registers a prefab, replacing any prefab of the same name.
+func (w *World) DefinePrefab(name string, props *ShapeProps, components ...Component) *Prefab {
+	prefab := &Prefab{
+		Name:       name,
+		Props:      props,
+		Components: components,
+	}
+	w.addPrefab(prefab)
+	return prefab
+}
+
+func (w *World) addPrefab(prefab *Prefab) {
+	w.prefabMutex.Lock()
+	defer w.prefabMutex.Unlock()
+
+	if w.prefabs == nil {
+		w.prefabs = make(map[string]*Prefab)
+	}
+	w.prefabs[prefab.Name] = prefab
+}
+
+// Prefab returns the prefab registered under name, or nil.
+func (w *World) Prefab(name string) *Prefab {
+	w.prefabMutex.RLock()
+	defer w.prefabMutex.RUnlock()
+	return w.prefabs[name]
+}
+
+type resolvedPrefab struct {
+	props      ShapeProps
+	properties map[string]interface{}
+	components []Component
+}
+
+func (w *World) resolvePrefab(name string, seen map[string]bool) (*resolvedPrefab, error) {
+	prefab := w.Prefab(name)
+	if prefab == nil {
+		return nil, fmt.Errorf("unknown prefab %q", name)
+	}
+	if seen[name] {
+		return nil, fmt.Errorf("prefab %q extends itself", name)
+	}
+	seen[name] = true
+
+	resolved := &resolvedPrefab{properties: make(map[string]interface{})}
+	if prefab.Base != "" {
+		base, err := w.resolvePrefab(prefab.Base, seen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve base of prefab %q: %w", name, err)
+		}
+		resolved = base
+	}
+
+	if prefab.Props != nil {
+		overlayProps(&resolved.props, prefab.Props)
+	}
+	for key, value := range resolved.props.ApplyProperties(prefab.Properties) {
+		resolved.properties[key] = value
+	}
+	resolved.components = append(resolved.components, prefab.Components...)
+
+	return resolved, nil
+}
+
+// overlayProps copies the non-zero fields of src over dst.
+func overlayProps(dst, src *ShapeProps) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		if !s.Field(i).IsZero() {
+			d.Field(i).Set(s.Field(i))
+		}
+	}
+}
+
+func cloneComponent(c Component) Component {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return c
+	}
+
+	clone := reflect.New(v.Elem().Type())
+	clone.Elem().Set(v.Elem())
+	return clone.Interface()
+}
+
+// Spawn registers a new shape from the named prefab with its top-left corner
+// at position. overrides are applied last, the way Properties are.
+func (w *World) Spawn(name string, position Vector2, overrides map[string]interface{}) (*Shape, error) {
+	resolved, err := w.resolvePrefab(name, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	props := resolved.props
+	props.X = position.X
+	props.Y = position.Y
+	for key, value := range props.ApplyProperties(overrides) {
+		resolved.properties[key] = value
+	}
+
+	shape := NewShape(&props)
+	w.Register(shape)
+	shape.SetProps(resolved.properties)
+
+	for _, c := range resolved.components {
+		shape.AddComponent(cloneComponent(c))
+	}
+	return shape, nil
+}
+
+// PrefabItem returns a map item that spawns the named prefab in the cell.
+// Prefabs without a width or height take the cell's.
+func (w *World) PrefabItem(name string) func(position Vector2, width, height float64) {
+	return func(position Vector2, width, height float64) {
+		overrides := make(map[string]interface{})
+		if resolved, err := w.resolvePrefab(name, make(map[string]bool)); err == nil {
+			if resolved.props.Width == 0 {
+				overrides["Width"] = width
+			}
+			if resolved.props.Height == 0 {
+				overrides["Height"] = height
+			}
+		}
+
+		if _, err := w.Spawn(name, position, overrides); err != nil {
+			log.Printf("failed to spawn map item: %v", err)
+		}
+	}
+}
+
+// prefabItems adds a map item for every legend name that is a prefab and has
+// no item of its own.
+func (w *World) prefabItems(legend map[string]string, items MapItems) MapItems {
+	for char, name := range legend {
+		if _, ok := items[char]; !ok && w.Prefab(name) != nil {
+			items[char] = w.PrefabItem(name)
+		}
+	}
+	return items
+}
+
+// LoadPrefabs defines the prefabs of a JSON or YAML file, which maps prefab
+// names to their properties:
+//
+//	wall:
+//	  Tag: wall
+//	  Image: floor.png
+//	  Friction: 0.6
+//	ground:
+//	  extends: wall
+//	  Tag: ground
+//
+// Image paths are relative to the file. A prefab may extend one defined in
+// code, which is how file prefabs get components.
+func (w *World) LoadPrefabs(fsys fs.FS, filePath string) error {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read prefab file %s: %w", filePath, err)
+	}
+
+	var definitions map[string]map[string]interface{}
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &definitions)
+	default:
+		err = json.Unmarshal(data, &definitions)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse prefab file %s: %w", filePath, err)
+	}
+
+	for name, properties := range definitions {
+		prefab := &Prefab{
+			Name:       name,
+			Properties: make(map[string]interface{}),
+		}
+
+		for key, value := range properties {
+			switch strings.ToLower(key) {
+			case "extends":
+				prefab.Base = fmt.Sprint(value)
+			case "image":
+				img, err := LoadImageFromFS(fsys, path.Join(path.Dir(filePath), fmt.Sprint(value)))
+				if err != nil {
+					return fmt.Errorf("failed to load image of prefab %q: %w", name, err)
+				}
+				prefab.Props = &ShapeProps{Image: img}
+			default:
+				prefab.Properties[key] = value
+			}
+		}
+
+		w.addPrefab(prefab)
+	}
+	return nil
+}
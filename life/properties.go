@@ -0,0 +1,155 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var colorType = reflect.TypeOf((*color.Color)(nil)).Elem()
+
+// fieldByName finds an exported field of a struct value, matching the name
+// exactly first and then case-insensitively.
+func fieldByName(v reflect.Value, name string) reflect.Value {
+	if field := v.FieldByName(name); field.IsValid() && field.CanSet() {
+		return field
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous || !f.IsExported() {
+			continue
+		}
+		if strings.EqualFold(f.Name, name) {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// assignValue stores value into field, converting between numbers, strings,
+// booleans and colors where that makes sense. It reports whether it could.
+func assignValue(field reflect.Value, value interface{}) bool {
+	if value == nil || !field.CanSet() {
+		return false
+	}
+
+	v := reflect.ValueOf(value)
+
+	if field.Type() == colorType {
+		switch c := value.(type) {
+		case color.Color:
+			field.Set(reflect.ValueOf(&c).Elem())
+			return true
+		case string:
+			parsed, err := ParseHexColor(c)
+			if err != nil {
+				return false
+			}
+			field.Set(reflect.ValueOf(&parsed).Elem())
+			return true
+		}
+		return false
+	}
+
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(value); ok {
+			field.SetFloat(f)
+			return true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, ok := toFloat(value); ok {
+			field.SetInt(int64(f))
+			return true
+		}
+	case reflect.Bool:
+		switch b := value.(type) {
+		case bool:
+			field.SetBool(b)
+			return true
+		case string:
+			if parsed, err := strconv.ParseBool(b); err == nil {
+				field.SetBool(parsed)
+				return true
+			}
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			field.SetString(s)
+			return true
+		}
+	}
+
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return true
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// ParseHexColor parses "#rgb", "#rrggbb" and "#aarrggbb", the last being the
+// order Tiled writes colors in.
+func ParseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	a := uint8(n >> 24)
+	r := uint8(n >> 16)
+	g := uint8(n >> 8)
+	b := uint8(n)
+
+	// color.RGBA is alpha-premultiplied.
+	return color.NRGBA{R: r, G: g, B: b, A: a}, nil
+}
+
+// ApplyProperties sets the ShapeProps fields named by properties and returns
+// the properties that do not name a field.
+func (props *ShapeProps) ApplyProperties(properties map[string]interface{}) map[string]interface{} {
+	rest := make(map[string]interface{})
+	v := reflect.ValueOf(props).Elem()
+
+	for name, value := range properties {
+		field := fieldByName(v, name)
+		if !field.IsValid() || !assignValue(field, value) {
+			rest[name] = value
+		}
+	}
+	return rest
+}
@@ -0,0 +1,322 @@
+package life
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ByteArena/box2d"
+)
+
+// RayHit is where a ray or a cast shape first touches a shape. Point and
+// Normal are in world pixels, and Fraction is how far along the ray or
+// translation the hit is, from 0 to 1.
+type RayHit struct {
+	Shape    *Shape
+	Point    Vector2
+	Normal   Vector2
+	Fraction float64
+}
+
+// QueryFilter decides which shapes a query may return. A nil filter accepts
+// every shape. Ghost shapes are included unless the filter drops them.
+type QueryFilter func(shape *Shape) bool
+
+func (f QueryFilter) accepts(shape *Shape) bool {
+	return shape != nil && (f == nil || f(shape))
+}
+
+func toMeters(v Vector2) box2d.B2Vec2 {
+	return box2d.MakeB2Vec2(PixelsToMeters(v.X), PixelsToMeters(v.Y))
+}
+
+func toPixels(v box2d.B2Vec2) Vector2 {
+	return Vector2{X: MetersToPixels(v.X), Y: MetersToPixels(v.Y)}
+}
+
+// RayCast returns the closest shape the segment from from to to passes
+// through. Good for line of sight and ground checks.
+func (w *World) RayCast(from, to Vector2, filter QueryFilter) (RayHit, bool) {
+	var (
+		closest RayHit
+		found   bool
+	)
+
+	if from == to {
+		return closest, false
+	}
+
+	w.PhysicsWorld.RayCast(func(fixture *box2d.B2Fixture, point, normal box2d.B2Vec2, fraction float64) float64 {
+		shape := shapeOf(fixture.GetBody())
+		if !filter.accepts(shape) {
+			return -1
+		}
+
+		closest = RayHit{
+			Shape:    shape,
+			Point:    toPixels(point),
+			Normal:   Vector2{X: normal.X, Y: normal.Y},
+			Fraction: fraction,
+		}
+		found = true
+
+		// Clip the ray here so only closer hits are reported from now on.
+		return fraction
+	}, toMeters(from), toMeters(to))
+
+	return closest, found
+}
+
+// RayCastAll returns every shape the segment passes through, nearest first,
+// with the first hit on each shape.
+func (w *World) RayCastAll(from, to Vector2, filter QueryFilter) []RayHit {
+	if from == to {
+		return nil
+	}
+
+	hits := make(map[*Shape]RayHit)
+	w.PhysicsWorld.RayCast(func(fixture *box2d.B2Fixture, point, normal box2d.B2Vec2, fraction float64) float64 {
+		shape := shapeOf(fixture.GetBody())
+		if !filter.accepts(shape) {
+			return -1
+		}
+
+		if hit, ok := hits[shape]; !ok || fraction < hit.Fraction {
+			hits[shape] = RayHit{
+				Shape:    shape,
+				Point:    toPixels(point),
+				Normal:   Vector2{X: normal.X, Y: normal.Y},
+				Fraction: fraction,
+			}
+		}
+		return 1
+	}, toMeters(from), toMeters(to))
+
+	result := make([]RayHit, 0, len(hits))
+	for _, hit := range hits {
+		result = append(result, hit)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Fraction < result[j].Fraction
+	})
+	return result
+}
+
+// QueryRect returns the shapes whose bodies overlap the rectangle.
+func (w *World) QueryRect(rect Rect) []*Shape {
+	return w.QueryRectFiltered(rect, nil)
+}
+
+// QueryRectFiltered is QueryRect with a filter.
+func (w *World) QueryRectFiltered(rect Rect, filter QueryFilter) []*Shape {
+	box := box2d.MakeB2PolygonShape()
+	box.SetAsBoxFromCenterAndAngle(
+		PixelsToMeters(math.Max(rect.Width, 0.01)/2),
+		PixelsToMeters(math.Max(rect.Height, 0.01)/2),
+		box2d.MakeB2Vec2(PixelsToMeters(rect.X+rect.Width/2), PixelsToMeters(rect.Y+rect.Height/2)),
+		0,
+	)
+
+	identity := box2d.MakeB2Transform()
+	identity.SetIdentity()
+
+	var result []*Shape
+	seen := make(map[*Shape]bool)
+
+	w.PhysicsWorld.QueryAABB(func(fixture *box2d.B2Fixture) bool {
+		shape := shapeOf(fixture.GetBody())
+		if seen[shape] || !filter.accepts(shape) {
+			return true
+		}
+
+		xf := fixture.GetBody().GetTransform()
+		for child := 0; child < fixture.GetShape().GetChildCount(); child++ {
+			if box2d.B2TestOverlapShapes(&box, 0, fixture.GetShape(), child, identity, xf) {
+				seen[shape] = true
+				result = append(result, shape)
+				break
+			}
+		}
+		return true
+	}, rectAABB(rect))
+
+	return result
+}
+
+// QueryPoint returns the shapes whose bodies contain the point, for mouse
+// picking and the like.
+func (w *World) QueryPoint(p Vector2) []*Shape {
+	point := toMeters(p)
+
+	var result []*Shape
+	seen := make(map[*Shape]bool)
+
+	w.PhysicsWorld.QueryAABB(func(fixture *box2d.B2Fixture) bool {
+		shape := shapeOf(fixture.GetBody())
+		if shape != nil && !seen[shape] && fixture.TestPoint(point) {
+			seen[shape] = true
+			result = append(result, shape)
+		}
+		return true
+	}, rectAABB(Rect{X: p.X, Y: p.Y}))
+
+	return result
+}
+
+// castGeometry returns the collision shapes of s and the transform they are
+// at. Registered shapes use their body; others are built from their type and
+// size, as Register would.
+func castGeometry(s *Shape) ([]box2d.B2ShapeInterface, box2d.B2Transform) {
+	var geometry []box2d.B2ShapeInterface
+	xf := box2d.MakeB2Transform()
+
+	if s.Body != nil {
+		for fixture := s.Body.GetFixtureList(); fixture != nil; fixture = fixture.GetNext() {
+			geometry = append(geometry, fixture.GetShape())
+		}
+		return geometry, s.Body.GetTransform()
+	}
+
+	xf.Set(box2d.MakeB2Vec2(PixelsToMeters(s.X+s.Width/2), PixelsToMeters(s.Y+s.Height/2)), s.RotationAngle)
+
+	if s.Type == ShapeCircle {
+		circle := box2d.MakeB2CircleShape()
+		circle.SetRadius(PixelsToMeters(s.Radius))
+		return append(geometry, &circle), xf
+	}
+
+	box := box2d.MakeB2PolygonShape()
+	box.SetAsBox(PixelsToMeters(s.Width/2), PixelsToMeters(s.Height/2))
+	return append(geometry, &box), xf
+}
+
+// ShapeCast sweeps the shape from where it is along translation, without
+// moving it, and returns the first shape it would touch. The cast shape does
+// not need to be registered and never hits itself. Good for shot prediction
+// and checking whether a move is free.
+func (w *World) ShapeCast(shape *Shape, translation Vector2, filter QueryFilter) (RayHit, bool) {
+	geometry, start := castGeometry(shape)
+	delta := toMeters(translation)
+
+	end := start
+	end.P = box2d.B2Vec2Add(start.P, delta)
+
+	// The broad-phase query covers everything the shape passes over.
+	swept := box2d.MakeB2AABB()
+	first := true
+	for _, g := range geometry {
+		for child := 0; child < g.GetChildCount(); child++ {
+			for _, xf := range []box2d.B2Transform{start, end} {
+				var aabb box2d.B2AABB
+				g.ComputeAABB(&aabb, xf, child)
+				if first {
+					swept = aabb
+					first = false
+				} else {
+					swept.CombineTwoInPlace(swept, aabb)
+				}
+			}
+		}
+	}
+	if first {
+		return RayHit{}, false
+	}
+
+	var (
+		best  RayHit
+		found bool
+	)
+	best.Fraction = math.Inf(1)
+
+	w.PhysicsWorld.QueryAABB(func(fixture *box2d.B2Fixture) bool {
+		target := shapeOf(fixture.GetBody())
+		if target == shape || !filter.accepts(target) {
+			return true
+		}
+
+		targetXf := fixture.GetBody().GetTransform()
+		for _, g := range geometry {
+			for childA := 0; childA < g.GetChildCount(); childA++ {
+				for childB := 0; childB < fixture.GetShape().GetChildCount(); childB++ {
+					t, ok := timeOfImpact(g, childA, start, delta, fixture.GetShape(), childB, targetXf)
+					if !ok || t >= best.Fraction {
+						continue
+					}
+
+					point, normal := contactAt(g, childA, start, delta, t, fixture.GetShape(), childB, targetXf)
+					best = RayHit{Shape: target, Point: point, Normal: normal, Fraction: t}
+					found = true
+				}
+			}
+		}
+		return true
+	}, swept)
+
+	return best, found
+}
+
+func timeOfImpact(a box2d.B2ShapeInterface, childA int, start box2d.B2Transform, delta box2d.B2Vec2, b box2d.B2ShapeInterface, childB int, xfB box2d.B2Transform) (float64, bool) {
+	input := box2d.B2TOIInput{
+		ProxyA: box2d.MakeB2DistanceProxy(),
+		ProxyB: box2d.MakeB2DistanceProxy(),
+		TMax:   1,
+	}
+	input.ProxyA.Set(a, childA)
+	input.ProxyB.Set(b, childB)
+
+	angleA := start.Q.GetAngle()
+	input.SweepA = box2d.B2Sweep{
+		C0: start.P,
+		C:  box2d.B2Vec2Add(start.P, delta),
+		A0: angleA,
+		A:  angleA,
+	}
+
+	angleB := xfB.Q.GetAngle()
+	input.SweepB = box2d.B2Sweep{
+		C0: xfB.P,
+		C:  xfB.P,
+		A0: angleB,
+		A:  angleB,
+	}
+
+	var output box2d.B2TOIOutput
+	box2d.B2TimeOfImpact(&output, &input)
+
+	switch output.State {
+	case box2d.B2TOIOutput_State.E_touching:
+		return output.T, true
+	case box2d.B2TOIOutput_State.E_overlapped:
+		return 0, true
+	}
+	return 0, false
+}
+
+// contactAt returns the closest point on b to the cast shape once it has
+// moved by fraction t, and the normal pointing from b towards it.
+func contactAt(a box2d.B2ShapeInterface, childA int, start box2d.B2Transform, delta box2d.B2Vec2, t float64, b box2d.B2ShapeInterface, childB int, xfB box2d.B2Transform) (Vector2, Vector2) {
+	xfA := start
+	xfA.P = box2d.B2Vec2Add(start.P, box2d.B2Vec2MulScalar(t, delta))
+
+	input := box2d.B2DistanceInput{
+		ProxyA:     box2d.MakeB2DistanceProxy(),
+		ProxyB:     box2d.MakeB2DistanceProxy(),
+		TransformA: xfA,
+		TransformB: xfB,
+		UseRadii:   true,
+	}
+	input.ProxyA.Set(a, childA)
+	input.ProxyB.Set(b, childB)
+
+	cache := box2d.MakeB2SimplexCache()
+	var output box2d.B2DistanceOutput
+	box2d.B2Distance(&output, &cache, &input)
+
+	normal := box2d.B2Vec2Sub(output.PointA, output.PointB)
+	if normal.Normalize() < box2d.B2_epsilon {
+		// Touching or overlapping: fall back to facing against the motion.
+		normal = box2d.MakeB2Vec2(-delta.X, -delta.Y)
+		normal.Normalize()
+	}
+
+	return toPixels(output.PointB), Vector2{X: normal.X, Y: normal.Y}
+}
@@ -0,0 +1,186 @@
+package life
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const ReplayVersion = 2
+
+// ReplayHeader holds the world settings a replay must be played back with.
+type ReplayHeader struct {
+	Version       int
+	Level         int
+	Seed          int64
+	Width         int
+	Height        int
+	G             Vector2
+	AirResistance float64
+	FixedStep     float64
+	MaxSubSteps   int
+	TimeScale     float64
+	MergeTiles    TileMerge
+}
+
+// ReplayFrame is the input that took effect at Frame, counted from the start
+// of the recording. It stays in effect until the next ReplayFrame.
+type ReplayFrame struct {
+	Frame int64
+	Input InputState
+}
+
+type Replay struct {
+	Header ReplayHeader
+	Length int64
+	Frames []ReplayFrame
+}
+
+type replayRecorder struct {
+	replay *Replay
+	last   *InputState
+}
+
+func (r *replayRecorder) record(state InputState) {
+	if r.last == nil || !r.last.Equal(state) {
+		r.replay.Frames = append(r.replay.Frames, ReplayFrame{
+			Frame: r.replay.Length,
+			Input: state,
+		})
+		r.last = &state
+	}
+
+	r.replay.Length++
+}
+
+// ReplayInput is an InputSource that plays back a recorded Replay one step at
+// a time. Once the recording runs out it keeps reporting the last recorded
+// input, so keys held at the end stay held, and Done reports true.
+type ReplayInput struct {
+	replay *Replay
+	frame  int64
+	next   int
+	state  InputState
+}
+
+func NewReplayInput(replay *Replay) *ReplayInput {
+	return &ReplayInput{
+		replay: replay,
+	}
+}
+
+func (r *ReplayInput) Poll() InputState {
+	if r.Done() {
+		return r.state
+	}
+
+	for r.next < len(r.replay.Frames) && r.replay.Frames[r.next].Frame <= r.frame {
+		r.state = r.replay.Frames[r.next].Input
+		r.next++
+	}
+
+	r.frame++
+	return r.state
+}
+
+func (r *ReplayInput) Done() bool {
+	return r.frame >= r.replay.Length
+}
+
+func SaveReplay(path string, replay *Replay) error {
+	data, err := json.Marshal(replay)
+	if err != nil {
+		return fmt.Errorf("failed to encode replay: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write replay file %s: %w", path, err)
+	}
+	return nil
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file %s: %w", path, err)
+	}
+
+	var replay Replay
+	if err := json.Unmarshal(data, &replay); err != nil {
+		return nil, fmt.Errorf("failed to decode replay file %s: %w", path, err)
+	}
+
+	if replay.Header.Version != ReplayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d in %s", replay.Header.Version, path)
+	}
+	return &replay, nil
+}
+
+// StartRecording records every step's input from now on. Start it before the
+// level is selected so the replay begins from the level's initial state.
+func (w *World) StartRecording() {
+	w.recorder = &replayRecorder{
+		replay: &Replay{
+			Header: ReplayHeader{
+				Version:       ReplayVersion,
+				Level:         w.CurrentLevel,
+				Seed:          w.Seed,
+				Width:         w.Width,
+				Height:        w.Height,
+				G:             w.G,
+				AirResistance: w.AirResistance,
+				FixedStep:     w.FixedStep,
+				MaxSubSteps:   w.MaxSubSteps,
+				TimeScale:     w.TimeScale,
+				MergeTiles:    w.MergeTiles,
+			},
+		},
+	}
+}
+
+func (w *World) StopRecording() *Replay {
+	if w.recorder == nil {
+		return nil
+	}
+
+	replay := w.recorder.replay
+	w.recorder = nil
+	return replay
+}
+
+func (w *World) IsRecording() bool {
+	return w.recorder != nil
+}
+
+// PlayReplay restores the recorded world settings and feeds the recorded
+// input back through Update. Call it before the level is selected, i.e. before
+// Game.Run or SelectLevel.
+func (w *World) PlayReplay(replay *Replay) error {
+	if replay == nil {
+		return fmt.Errorf("replay is nil")
+	}
+	if replay.Header.Version != ReplayVersion {
+		return fmt.Errorf("unsupported replay version %d", replay.Header.Version)
+	}
+
+	header := replay.Header
+
+	w.Width = header.Width
+	w.Height = header.Height
+	w.G = header.G
+	w.PhysicsWorld.SetGravity(box2dGravity(header.G))
+	w.AirResistance = header.AirResistance
+	w.FixedStep = header.FixedStep
+	w.MaxSubSteps = header.MaxSubSteps
+	w.TimeScale = header.TimeScale
+	w.MergeTiles = header.MergeTiles
+	w.SetSeed(header.Seed)
+
+	w.CurrentLevel = header.Level
+	w.Input = NewReplayInput(replay)
+	w.recorder = nil
+	w.accumulator = 0
+	w.frame = 0
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package life
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Scene is an overlay such as a pause menu, dialog or HUD that is pushed on
+// top of the running level. The level is always the bottom of the stack.
+type Scene struct {
+	Name string
+
+	// Persistent scenes, such as a HUD, stay on the stack when the level is
+	// unloaded. The others are removed with it, top first.
+	Persistent bool
+
+	// TickBelow keeps the scenes and level underneath ticking while this
+	// scene is on the stack. RenderBelow keeps them drawing.
+	TickBelow   bool
+	RenderBelow bool
+
+	Init      func(world *World)
+	Tick      func(ld LoopData)
+	Render    func(screen *ebiten.Image)
+	OnDestroy func(world *World)
+}
+
+func (w *World) PushScene(scene *Scene) {
+	if scene == nil {
+		return
+	}
+
+	w.scenes = append(w.scenes, scene)
+
+	if scene.Init != nil {
+		scene.Init(w)
+	}
+}
+
+func (w *World) PopScene() *Scene {
+	if len(w.scenes) == 0 {
+		return nil
+	}
+
+	scene := w.scenes[len(w.scenes)-1]
+	w.scenes = w.scenes[:len(w.scenes)-1]
+
+	if scene.OnDestroy != nil {
+		scene.OnDestroy(w)
+	}
+
+	return scene
+}
+
+// RemoveScene takes a scene off the stack wherever it is.
+func (w *World) RemoveScene(scene *Scene) {
+	for i, s := range w.scenes {
+		if s == scene {
+			w.scenes = append(w.scenes[:i], w.scenes[i+1:]...)
+
+			if scene.OnDestroy != nil {
+				scene.OnDestroy(w)
+			}
+			return
+		}
+	}
+}
+
+func (w *World) ClearScenes() {
+	for len(w.scenes) > 0 {
+		w.PopScene()
+	}
+}
+
+// clearLevelScenes removes the scenes that are not Persistent.
+func (w *World) clearLevelScenes() {
+	for i := len(w.scenes) - 1; i >= 0; i-- {
+		// OnDestroy may change the stack.
+		if i < len(w.scenes) && !w.scenes[i].Persistent {
+			w.RemoveScene(w.scenes[i])
+		}
+	}
+}
+
+func (w *World) TopScene() *Scene {
+	if len(w.scenes) == 0 {
+		return nil
+	}
+	return w.scenes[len(w.scenes)-1]
+}
+
+func (w *World) Scenes() []*Scene {
+	result := make([]*Scene, len(w.scenes))
+	copy(result, w.scenes)
+	return result
+}
+
+// tickingScenes returns the scenes that tick this step, bottom first, and
+// whether the level underneath them ticks too.
+func (w *World) tickingScenes() ([]*Scene, bool) {
+	return w.sceneRange(func(scene *Scene) bool { return scene.TickBelow })
+}
+
+// renderingScenes returns the scenes that draw this frame, bottom first, and
+// whether the level underneath them draws too.
+func (w *World) renderingScenes() ([]*Scene, bool) {
+	return w.sceneRange(func(scene *Scene) bool { return scene.RenderBelow })
+}
+
+func (w *World) sceneRange(passesBelow func(scene *Scene) bool) ([]*Scene, bool) {
+	start := len(w.scenes)
+	levelIncluded := true
+
+	for start > 0 {
+		start--
+		if !passesBelow(w.scenes[start]) {
+			levelIncluded = false
+			break
+		}
+	}
+
+	result := make([]*Scene, len(w.scenes)-start)
+	copy(result, w.scenes[start:])
+	return result, levelIncluded
+}
+
+func (w *World) renderScenes(screen *ebiten.Image) {
+	scenes, _ := w.renderingScenes()
+	for _, scene := range scenes {
+		if scene.Render != nil {
+			scene.Render(screen)
+		}
+	}
+}
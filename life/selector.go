@@ -0,0 +1,417 @@
+package life
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A selector picks shapes the way CSS picks elements:
+//
+//	ground              shapes tagged "ground"
+//	#player             shapes named "player"
+//	.circle             shapes of type circle
+//	*                   every shape
+//	[IsBody=true]       field or custom property predicates, with =, !=, <,
+//	[ZIndex>10]         <=, > and >=; [Ghost] alone means set and non-zero
+//	:within(x, y, r)    centers within r pixels of a point
+//	:near(#player, r)   centers within r pixels of any shape the inner
+//	                    selector matches
+//	:not(selector)      shapes the inner selector does not match
+//
+// Parts written together must all match, as in "enemy.circle[Speed>3]".
+// Commas join alternatives: "ground, wall".
+type selector []compoundSelector
+
+type compoundSelector struct {
+	tag     string
+	name    string
+	types   []ShapeType
+	attrs   []attrPredicate
+	pseudos []pseudoSelector
+}
+
+type attrPredicate struct {
+	name  string
+	op    string
+	value string
+}
+
+type pseudoSelector struct {
+	kind   string
+	point  Vector2
+	radius float64
+	inner  selector
+}
+
+func parseSelector(text string) (selector, error) {
+	var parsed selector
+	for _, part := range splitTopLevel(text, ',') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid selector %q: empty alternative", text)
+		}
+
+		compound, err := parseCompound(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", text, err)
+		}
+		parsed = append(parsed, compound)
+	}
+
+	return parsed, nil
+}
+
+// splitTopLevel splits on sep outside of parentheses, brackets and quotes.
+func splitTopLevel(text string, sep rune) []string {
+	var (
+		parts []string
+		depth int
+		quote rune
+		start int
+	)
+
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == sep && depth == 0:
+			parts = append(parts, text[start:i])
+			start = i + len(string(r))
+		}
+	}
+	return append(parts, text[start:])
+}
+
+func isIdentRune(r byte) bool {
+	return r == '_' || r == '-' ||
+		(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func readIdent(text string, i int) (string, int) {
+	start := i
+	for i < len(text) && isIdentRune(text[i]) {
+		i++
+	}
+	return text[start:i], i
+}
+
+// readGroup returns the text between the opening character at text[i] and
+// its matching close, and the index after the close.
+func readGroup(text string, i int, open, close byte) (string, int, error) {
+	depth := 0
+	var quote byte
+	for j := i; j < len(text); j++ {
+		c := text[j]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return text[i+1 : j], j + 1, nil
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("missing %q", close)
+}
+
+func parseCompound(text string) (compoundSelector, error) {
+	var compound compoundSelector
+	i := 0
+
+	if text[0] == '*' {
+		i++
+	} else if isIdentRune(text[0]) {
+		compound.tag, i = readIdent(text, 0)
+	}
+
+	for i < len(text) {
+		switch text[i] {
+		case '#', '.':
+			prefix := text[i]
+			ident, next := readIdent(text, i+1)
+			if ident == "" {
+				return compound, fmt.Errorf("expected a name after %q", prefix)
+			}
+			if prefix == '#' {
+				compound.name = ident
+			} else {
+				compound.types = append(compound.types, ShapeType(ident))
+			}
+			i = next
+
+		case '[':
+			inner, next, err := readGroup(text, i, '[', ']')
+			if err != nil {
+				return compound, err
+			}
+			attr, err := parseAttr(inner)
+			if err != nil {
+				return compound, err
+			}
+			compound.attrs = append(compound.attrs, attr)
+			i = next
+
+		case ':':
+			kind, next := readIdent(text, i+1)
+			if next >= len(text) || text[next] != '(' {
+				return compound, fmt.Errorf("expected arguments after :%s", kind)
+			}
+			args, after, err := readGroup(text, next, '(', ')')
+			if err != nil {
+				return compound, err
+			}
+			pseudo, err := parsePseudo(kind, args)
+			if err != nil {
+				return compound, err
+			}
+			compound.pseudos = append(compound.pseudos, pseudo)
+			i = after
+
+		default:
+			return compound, fmt.Errorf("unexpected %q", text[i:])
+		}
+	}
+
+	return compound, nil
+}
+
+func parseAttr(text string) (attrPredicate, error) {
+	for _, op := range []string{"!=", "<=", ">=", "=", "<", ">"} {
+		if name, value, ok := strings.Cut(text, op); ok {
+			name = strings.TrimSpace(name)
+			value = strings.Trim(strings.TrimSpace(value), `"'`)
+			if name == "" {
+				return attrPredicate{}, fmt.Errorf("missing attribute name in [%s]", text)
+			}
+			return attrPredicate{name: name, op: op, value: value}, nil
+		}
+	}
+
+	name := strings.TrimSpace(text)
+	if name == "" {
+		return attrPredicate{}, fmt.Errorf("empty attribute []")
+	}
+	return attrPredicate{name: name}, nil
+}
+
+func parsePseudo(kind, args string) (pseudoSelector, error) {
+	parts := splitTopLevel(args, ',')
+
+	switch kind {
+	case "within":
+		if len(parts) != 3 {
+			return pseudoSelector{}, fmt.Errorf(":within takes x, y and a radius")
+		}
+		var values [3]float64
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return pseudoSelector{}, fmt.Errorf(":within: %q is not a number", part)
+			}
+			values[i] = v
+		}
+		return pseudoSelector{kind: kind, point: Vector2{X: values[0], Y: values[1]}, radius: values[2]}, nil
+
+	case "near":
+		if len(parts) < 2 {
+			return pseudoSelector{}, fmt.Errorf(":near takes a selector and a radius")
+		}
+		radius, err := strconv.ParseFloat(strings.TrimSpace(parts[len(parts)-1]), 64)
+		if err != nil {
+			return pseudoSelector{}, fmt.Errorf(":near: %q is not a number", parts[len(parts)-1])
+		}
+		inner, err := parseSelector(strings.Join(parts[:len(parts)-1], ","))
+		if err != nil {
+			return pseudoSelector{}, err
+		}
+		return pseudoSelector{kind: kind, radius: radius, inner: inner}, nil
+
+	case "not":
+		inner, err := parseSelector(args)
+		if err != nil {
+			return pseudoSelector{}, err
+		}
+		return pseudoSelector{kind: kind, inner: inner}, nil
+	}
+
+	return pseudoSelector{}, fmt.Errorf("unknown pseudo-selector :%s", kind)
+}
+
+// selectorMatch evaluates a selector against the world's shapes. Shapes
+// found by :near are computed once per query.
+type selectorMatch struct {
+	near   map[*pseudoSelector][]*Shape
+	shapes []*Shape
+}
+
+func (m *selectorMatch) matches(s selector, shape *Shape) bool {
+	for i := range s {
+		if m.matchesCompound(&s[i], shape) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *selectorMatch) matchesCompound(c *compoundSelector, shape *Shape) bool {
+	if c.tag != "" && shape.Tag != c.tag {
+		return false
+	}
+	if c.name != "" && shape.Name != c.name {
+		return false
+	}
+	for _, t := range c.types {
+		if shape.Type != t {
+			return false
+		}
+	}
+	for _, attr := range c.attrs {
+		if !attr.matches(shape) {
+			return false
+		}
+	}
+	for i := range c.pseudos {
+		if !m.matchesPseudo(&c.pseudos[i], shape) {
+			return false
+		}
+	}
+	return true
+}
+
+func shapeCenter(s *Shape) Vector2 {
+	return Vector2{X: s.X + s.Width/2, Y: s.Y + s.Height/2}
+}
+
+func (m *selectorMatch) matchesPseudo(p *pseudoSelector, shape *Shape) bool {
+	switch p.kind {
+	case "within":
+		return shapeCenter(shape).Sub(p.point).Length() <= p.radius
+
+	case "near":
+		others, ok := m.near[p]
+		if !ok {
+			for _, obj := range m.shapes {
+				if m.matches(p.inner, obj) {
+					others = append(others, obj)
+				}
+			}
+			m.near[p] = others
+		}
+
+		center := shapeCenter(shape)
+		for _, other := range others {
+			if other != shape && shapeCenter(other).Sub(center).Length() <= p.radius {
+				return true
+			}
+		}
+		return false
+
+	case "not":
+		return !m.matches(p.inner, shape)
+	}
+	return false
+}
+
+func (a attrPredicate) matches(shape *Shape) bool {
+	actual := shape.Get(a.name)
+
+	if a.op == "" {
+		return actual != nil && !reflect.ValueOf(actual).IsZero()
+	}
+	if actual == nil {
+		return a.op == "!="
+	}
+
+	actualNumber, actualIsNumber := toFloat(actual)
+	wantedNumber, wantedIsNumber := toFloat(a.value)
+	if _, isString := actual.(string); isString {
+		actualIsNumber = false
+	}
+
+	if actualIsNumber && wantedIsNumber {
+		switch a.op {
+		case "=":
+			return math.Abs(actualNumber-wantedNumber) < 1e-9
+		case "!=":
+			return math.Abs(actualNumber-wantedNumber) >= 1e-9
+		case "<":
+			return actualNumber < wantedNumber
+		case "<=":
+			return actualNumber <= wantedNumber
+		case ">":
+			return actualNumber > wantedNumber
+		case ">=":
+			return actualNumber >= wantedNumber
+		}
+	}
+
+	equal := strings.EqualFold(fmt.Sprint(actual), a.value)
+	switch a.op {
+	case "=":
+		return equal
+	case "!=":
+		return !equal
+	}
+	return false
+}
+
+// QuerySelectorAll returns the registered shapes matching the selector, in
+// registration order. See the selector syntax above.
+func (w *World) QuerySelectorAll(query string) ([]*Shape, error) {
+	s, err := parseSelector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	all := w.GetAllElements()
+	match := &selectorMatch{
+		near:   make(map[*pseudoSelector][]*Shape),
+		shapes: all,
+	}
+
+	// A single alternative with a tag or a name only has to look at the
+	// shapes the index has under it.
+	candidates := all
+	if len(s) == 1 {
+		if s[0].name != "" {
+			candidates = w.GetElementsByName(s[0].name)
+		} else if s[0].tag != "" {
+			candidates = w.GetElementsByTagName(s[0].tag)
+		}
+	}
+
+	var result []*Shape
+	for _, obj := range candidates {
+		if match.matches(s, obj) {
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+// QuerySelector returns the first shape matching the selector, or nil.
+func (w *World) QuerySelector(query string) (*Shape, error) {
+	shapes, err := w.QuerySelectorAll(query)
+	if err != nil || len(shapes) == 0 {
+		return nil, err
+	}
+	return shapes[0], nil
+}
@@ -0,0 +1,262 @@
+package life
+
+import (
+	"embed"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed shaders/*.kage
+var builtinShaders embed.FS
+
+// Effect draws a shape, or a tile layer, through a Kage shader. Shaders
+// should use //kage:unit pixels. Besides Uniforms, every shader gets Time,
+// the world's game time in seconds, and Tint, the effect's color as a
+// non-premultiplied vec4.
+type Effect struct {
+	Name     string
+	Tint     color.Color
+	Uniforms map[string]interface{}
+
+	// Padding is how many pixels around the image the shader may draw into,
+	// for effects such as outlines and glows.
+	Padding float64
+
+	shader *ebiten.Shader
+	padded *ebiten.Image
+	err    error
+}
+
+// NewEffect compiles a Kage shader. Compile errors are returned, never
+// panicked.
+func NewEffect(name string, source []byte) (effect *Effect, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			effect, err = nil, fmt.Errorf("failed to compile shader %s: %v", name, r)
+		}
+	}()
+
+	shader, err := ebiten.NewShader(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile shader %s: %w", name, err)
+	}
+
+	return &Effect{
+		Name:     name,
+		Tint:     color.White,
+		Uniforms: make(map[string]interface{}),
+		shader:   shader,
+	}, nil
+}
+
+// LoadEffect reads and compiles a Kage shader from fsys.
+func LoadEffect(fsys fs.FS, path string) (*Effect, error) {
+	source, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shader %s: %w", path, err)
+	}
+	return NewEffect(path, source)
+}
+
+var (
+	builtinShaderCache = make(map[string]*ebiten.Shader)
+	builtinShaderMutex sync.Mutex
+)
+
+// builtinEffect returns a new effect sharing the compiled built-in shader of
+// that name.
+func builtinEffect(name string, uniforms map[string]interface{}) (*Effect, error) {
+	builtinShaderMutex.Lock()
+	defer builtinShaderMutex.Unlock()
+
+	shader, ok := builtinShaderCache[name]
+	if !ok {
+		source, err := builtinShaders.ReadFile("shaders/" + name + ".kage")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in shader %s: %w", name, err)
+		}
+
+		effect, err := NewEffect(name, source)
+		if err != nil {
+			return nil, err
+		}
+		shader = effect.shader
+		builtinShaderCache[name] = shader
+	}
+
+	return &Effect{
+		Name:     name,
+		Tint:     color.White,
+		Uniforms: uniforms,
+		shader:   shader,
+	}, nil
+}
+
+// NewHitFlashEffect blends the shape towards the Tint by its "Amount"
+// uniform, from 0 to 1.
+func NewHitFlashEffect(flash color.Color) (*Effect, error) {
+	effect, err := builtinEffect("hitflash", map[string]interface{}{"Amount": 0.0})
+	if err != nil {
+		return nil, err
+	}
+	effect.Tint = flash
+	return effect, nil
+}
+
+// NewOutlineEffect draws a border of the given color around the opaque
+// pixels of the shape, "Width" pixels wide.
+func NewOutlineEffect(width float64, outline color.Color) (*Effect, error) {
+	effect, err := builtinEffect("outline", map[string]interface{}{"Width": width})
+	if err != nil {
+		return nil, err
+	}
+	effect.Tint = outline
+	effect.Padding = width
+	return effect, nil
+}
+
+// NewDissolveEffect eats the shape away with noise as its "Progress" uniform
+// goes from 0 to 1, leaving an edge of the given color "EdgeWidth" wide.
+func NewDissolveEffect(edge color.Color) (*Effect, error) {
+	effect, err := builtinEffect("dissolve", map[string]interface{}{
+		"Progress":  0.0,
+		"EdgeWidth": 0.08,
+	})
+	if err != nil {
+		return nil, err
+	}
+	effect.Tint = edge
+	return effect, nil
+}
+
+// NewGrayscaleEffect removes the shape's colors by its "Amount" uniform, 1
+// by default.
+func NewGrayscaleEffect() (*Effect, error) {
+	return builtinEffect("grayscale", map[string]interface{}{"Amount": 1.0})
+}
+
+// NewGlowEffect surrounds the shape with a halo of the given color reaching
+// "Radius" pixels, as strong as "Intensity".
+func NewGlowEffect(radius float64, glow color.Color) (*Effect, error) {
+	effect, err := builtinEffect("glow", map[string]interface{}{
+		"Radius":    radius,
+		"Intensity": 1.0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	effect.Tint = glow
+	effect.Padding = radius
+	return effect, nil
+}
+
+// Set sets a uniform of the effect.
+func (e *Effect) Set(name string, value interface{}) *Effect {
+	if e.Uniforms == nil {
+		e.Uniforms = make(map[string]interface{})
+	}
+	e.Uniforms[name] = value
+	return e
+}
+
+// Err returns the error that stopped the effect from drawing, such as a
+// uniform of the wrong type. Shapes whose effect failed are drawn without
+// it.
+func (e *Effect) Err() error {
+	return e.err
+}
+
+func (e *Effect) uniforms(time float64) map[string]interface{} {
+	uniforms := make(map[string]interface{}, len(e.Uniforms)+2)
+	for name, value := range e.Uniforms {
+		uniforms[name] = value
+	}
+
+	tint := e.Tint
+	if tint == nil {
+		tint = color.White
+	}
+	c := color.NRGBAModel.Convert(tint).(color.NRGBA)
+	uniforms["Tint"] = []float32{float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255, float32(c.A) / 255}
+	uniforms["Time"] = float32(time)
+	return uniforms
+}
+
+// draw draws src through the shader with the given transform. Panics from
+// ebiten, such as a uniform of the wrong type, are returned as errors and
+// remembered so the effect is not tried again.
+func (e *Effect) draw(dst, src *ebiten.Image, geoM ebiten.GeoM, colorScale ebiten.ColorScale, time float64) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to draw effect %s: %v", e.Name, r)
+		}
+		if err != nil && e.err == nil {
+			e.err = err
+			log.Print(err)
+		}
+	}()
+
+	source := src
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if padding := int(math.Ceil(e.Padding)); padding > 0 {
+		width += padding * 2
+		height += padding * 2
+
+		if e.padded == nil || e.padded.Bounds().Dx() != width || e.padded.Bounds().Dy() != height {
+			e.padded = ebiten.NewImage(width, height)
+		}
+		e.padded.Clear()
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(padding), float64(padding))
+		e.padded.DrawImage(src, op)
+		source = e.padded
+
+		var shifted ebiten.GeoM
+		shifted.Translate(-float64(padding), -float64(padding))
+		shifted.Concat(geoM)
+		geoM = shifted
+	}
+
+	op := &ebiten.DrawRectShaderOptions{
+		GeoM:       geoM,
+		ColorScale: colorScale,
+		Uniforms:   e.uniforms(time),
+	}
+	op.Images[0] = source
+	dst.DrawRectShader(width, height, e.shader, op)
+	return nil
+}
+
+// SetUniform sets a uniform of the shape's effect, if it has one.
+func (s *Shape) SetUniform(name string, value interface{}) {
+	if s.Effect != nil {
+		s.Effect.Set(name, value)
+	}
+}
+
+func (s *Shape) gameTime() float64 {
+	if s.world == nil {
+		return 0
+	}
+	return s.world.GameTime().Seconds()
+}
+
+// drawImage draws img with op, through the shape's effect when it has one
+// that works.
+func (s *Shape) drawImage(screen, img *ebiten.Image, op *ebiten.DrawImageOptions) {
+	if s.Effect != nil && s.Effect.err == nil {
+		if err := s.Effect.draw(screen, img, op.GeoM, op.ColorScale, s.gameTime()); err == nil {
+			return
+		}
+	}
+	screen.DrawImage(img, op)
+}
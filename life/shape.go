@@ -3,6 +3,8 @@ package life
 import (
 	"image/color"
 	"math"
+	"reflect"
+	"strings"
 
 	"github.com/ByteArena/box2d"
 	"github.com/hajimehoshi/ebiten/v2"
@@ -29,7 +31,7 @@ type Shape struct {
 	Width         float64
 	Height        float64
 	Radius        float64
-	RotationAngle float64
+	RotationAngle float64 // in radians
 	RotationSpeed float64
 	RotationLock  bool
 	Mass          float64
@@ -44,6 +46,9 @@ type Shape struct {
 	Border     *Border
 	Flip       struct{ X, Y bool }
 
+	// Effect, when set, draws the shape through a Kage shader.
+	Effect *Effect
+
 	IsBody   bool
 	Physics  bool
 	Velocity Vector2
@@ -65,6 +70,9 @@ type Shape struct {
 
 	world *World
 
+	prevX, prevY float64
+	prevRotation float64
+
 	cachedColorImage *ebiten.Image
 	lastBackground   color.Color
 
@@ -73,6 +81,18 @@ type Shape struct {
 
 	noCollideWith        map[string]bool
 	LastCollisionImpulse float64
+
+	properties map[string]interface{}
+
+	// The tag and name the world's index has the shape under.
+	indexedTag, indexedName string
+
+	// chains replaces the body's box with chain loops, in pixels relative
+	// to the top-left corner, for colliders of merged map tiles.
+	chains [][]Vector2
+
+	components    map[reflect.Type]Component
+	pendingTweens []*TweenHandle
 }
 
 type ShapeProps struct {
@@ -107,6 +127,7 @@ type ShapeProps struct {
 	Ghost                bool
 	Scale                float64
 	LastCollisionImpulse float64
+	Effect               *Effect
 }
 
 func NewShape(props *ShapeProps) *Shape {
@@ -187,6 +208,7 @@ func NewShape(props *ShapeProps) *Shape {
 		Ghost:                 props.Ghost,
 		noCollideWith:         make(map[string]bool),
 		LastCollisionImpulse:  props.LastCollisionImpulse,
+		Effect:                props.Effect,
 	}
 
 	if props.Radius > 0 && props.Type == ShapeCircle {
@@ -262,6 +284,26 @@ func (s *Shape) updatePhysicsInfo() {
 	}
 }
 
+func (s *Shape) storePreviousState() {
+	s.prevX = s.X
+	s.prevY = s.Y
+	s.prevRotation = s.RotationAngle
+}
+
+// renderState returns the position and rotation to draw the shape at,
+// interpolated between the last two physics steps of its world.
+func (s *Shape) renderState() (x, y, rotation float64) {
+	if s.world == nil {
+		return s.X, s.Y, s.RotationAngle
+	}
+
+	alpha := s.world.interpolationAlpha()
+	x = s.prevX + (s.X-s.prevX)*alpha
+	y = s.prevY + (s.Y-s.prevY)*alpha
+	rotation = s.prevRotation + (s.RotationAngle-s.prevRotation)*alpha
+	return x, y, rotation
+}
+
 func (s *Shape) requireInit() {
 	if s.Body == nil {
 		panic("Required: (*World).Register(Shape) pre-op.")
@@ -275,36 +317,37 @@ func (s *Shape) LockRotation(lock bool) {
 	s.Body.SetFixedRotation(lock)
 }
 
+// SetX and SetY move the shape like SetPosition, along one axis.
 func (s *Shape) SetX(x float64) {
-	s.X = x
-	centerX := x + s.Width/2
-	s.Body.SetTransform(box2d.MakeB2Vec2(PixelsToMeters(centerX), PixelsToMeters(s.Y+s.Height/2)), s.RotationAngle)
+	s.SetPosition(x, s.Y)
 }
 
 func (s *Shape) SetY(y float64) {
-	s.Y = y
-	centerY := y + s.Height/2
-	s.Body.SetTransform(box2d.MakeB2Vec2(PixelsToMeters(s.X+s.Width/2), PixelsToMeters(centerY)), s.RotationAngle)
+	s.SetPosition(s.X, y)
 }
 
+// SetPosition moves the shape and its body at once. The move is a teleport,
+// drawn at the new position without interpolating from the old one.
 func (s *Shape) SetPosition(x, y float64) {
 	s.X = x
 	s.Y = y
 	centerX := x + s.Width/2
 	centerY := y + s.Height/2
 	s.Body.SetTransform(box2d.MakeB2Vec2(PixelsToMeters(centerX), PixelsToMeters(centerY)), s.RotationAngle)
+	s.storePreviousState()
 }
 
+// SetRotation turns the shape and its body to angle, in radians like
+// RotationAngle.
 func (s *Shape) SetRotation(angle float64) {
 	s.RotationAngle = angle
 
-	s.Body.SetTransform(s.Body.GetPosition(), angle*Deg)
+	s.Body.SetTransform(s.Body.GetPosition(), angle)
 }
 
+// SetScale only changes how the shape is drawn; its body keeps its size.
 func (s *Shape) SetScale(scale float64) {
 	s.Scale = scale
-
-	s.Body.SetTransform(box2d.MakeB2Vec2(PixelsToMeters(s.X), PixelsToMeters(s.Y)), s.RotationAngle*Deg)
 }
 
 func (s *Shape) SetBackground(bg color.Color) {
@@ -324,30 +367,36 @@ func (s *Shape) getColorImage(width, height int) *ebiten.Image {
 }
 
 func (s *Shape) Draw(screen *ebiten.Image) {
+	s.DrawTransformed(screen, ebiten.GeoM{})
+}
+
+// DrawTransformed draws the shape with view applied after its own transform,
+// e.g. a camera's world-to-screen matrix.
+func (s *Shape) DrawTransformed(screen *ebiten.Image, view ebiten.GeoM) {
 
 	if s.Opacity <= 0 {
 		return
 	}
 
 	if s.Border != nil && s.Border.Width > 0 {
-		s.drawBorder(screen)
+		s.drawBorder(screen, view)
 	}
 
 	switch s.Type {
 	case ShapeRectangle:
-		s.drawRectangle(screen)
+		s.drawRectangle(screen, view)
 	case ShapeSquare:
-		s.drawSquare(screen)
+		s.drawSquare(screen, view)
 	case ShapeCircle:
-		s.drawCircle(screen)
+		s.drawCircle(screen, view)
 	case ShapeDot:
-		s.drawDot(screen)
+		s.drawDot(screen, view)
 	case ShapeLine:
-		s.drawLine(screen)
+		s.drawLine(screen, view)
 	}
 }
 
-func (s *Shape) drawRectangle(screen *ebiten.Image) {
+func (s *Shape) drawRectangle(screen *ebiten.Image, view ebiten.GeoM) {
 	op := &ebiten.DrawImageOptions{}
 
 	switch s.Pattern {
@@ -355,8 +404,8 @@ func (s *Shape) drawRectangle(screen *ebiten.Image) {
 
 		img := s.getColorImage(int(s.Width), int(s.Height))
 
-		s.applyTransformations(op, s.Width, s.Height)
-		screen.DrawImage(img, op)
+		s.applyTransformations(op, s.Width, s.Height, view)
+		s.drawImage(screen, img, op)
 
 	case PatternImage:
 		if s.Image != nil {
@@ -365,13 +414,13 @@ func (s *Shape) drawRectangle(screen *ebiten.Image) {
 			imgWidth := float64(imgBounds.Dx())
 			imgHeight := float64(imgBounds.Dy())
 
-			s.applyTransformations(op, imgWidth, imgHeight)
-			screen.DrawImage(s.Image, op)
+			s.applyTransformations(op, imgWidth, imgHeight, view)
+			s.drawImage(screen, s.Image, op)
 		}
 	}
 }
 
-func (s *Shape) drawSquare(screen *ebiten.Image) {
+func (s *Shape) drawSquare(screen *ebiten.Image, view ebiten.GeoM) {
 	op := &ebiten.DrawImageOptions{}
 
 	size := s.Width
@@ -384,8 +433,8 @@ func (s *Shape) drawSquare(screen *ebiten.Image) {
 
 		img := s.getColorImage(int(size), int(size))
 
-		s.applyTransformations(op, size, size)
-		screen.DrawImage(img, op)
+		s.applyTransformations(op, size, size, view)
+		s.drawImage(screen, img, op)
 
 	case PatternImage:
 		if s.Image != nil {
@@ -393,13 +442,13 @@ func (s *Shape) drawSquare(screen *ebiten.Image) {
 			imgWidth := float64(imgBounds.Dx())
 			imgHeight := float64(imgBounds.Dy())
 
-			s.applyTransformations(op, imgWidth, imgHeight)
-			screen.DrawImage(s.Image, op)
+			s.applyTransformations(op, imgWidth, imgHeight, view)
+			s.drawImage(screen, s.Image, op)
 		}
 	}
 }
 
-func (s *Shape) drawCircle(screen *ebiten.Image) {
+func (s *Shape) drawCircle(screen *ebiten.Image, view ebiten.GeoM) {
 	op := &ebiten.DrawImageOptions{}
 
 	switch s.Pattern {
@@ -418,8 +467,8 @@ func (s *Shape) drawCircle(screen *ebiten.Image) {
 			}
 		}
 
-		s.applyTransformations(op, s.Radius*2, s.Radius*2)
-		screen.DrawImage(img, op)
+		s.applyTransformations(op, s.Radius*2, s.Radius*2, view)
+		s.drawImage(screen, img, op)
 
 	case PatternImage:
 		if s.Image != nil {
@@ -427,27 +476,27 @@ func (s *Shape) drawCircle(screen *ebiten.Image) {
 			imgWidth := float64(imgBounds.Dx())
 			imgHeight := float64(imgBounds.Dy())
 
-			s.applyTransformations(op, imgWidth, imgHeight)
-			screen.DrawImage(s.Image, op)
+			s.applyTransformations(op, imgWidth, imgHeight, view)
+			s.drawImage(screen, s.Image, op)
 		}
 	}
 }
 
-func (s *Shape) drawLine(screen *ebiten.Image) {
+func (s *Shape) drawLine(screen *ebiten.Image, view ebiten.GeoM) {
 	op := &ebiten.DrawImageOptions{}
 
 	img := ebiten.NewImage(int(s.Width), int(s.Height))
 	img.Fill(s.Background)
 
-	s.applyTransformations(op, s.Width, s.Height)
-	screen.DrawImage(img, op)
+	s.applyTransformations(op, s.Width, s.Height, view)
+	s.drawImage(screen, img, op)
 }
 
-func (s *Shape) drawDot(screen *ebiten.Image) {
-	s.drawCircle(screen)
+func (s *Shape) drawDot(screen *ebiten.Image, view ebiten.GeoM) {
+	s.drawCircle(screen, view)
 }
 
-func (s *Shape) applyTransformations(op *ebiten.DrawImageOptions, originalWidth, originalHeight float64) {
+func (s *Shape) applyTransformations(op *ebiten.DrawImageOptions, originalWidth, originalHeight float64, view ebiten.GeoM) {
 
 	op.GeoM.Translate(-originalWidth/2, -originalHeight/2)
 
@@ -475,22 +524,28 @@ func (s *Shape) applyTransformations(op *ebiten.DrawImageOptions, originalWidth,
 
 	op.GeoM.Scale(scaleX, scaleY)
 
-	op.GeoM.Rotate(s.RotationAngle)
+	x, y, rotation := s.renderState()
+
+	op.GeoM.Rotate(rotation)
 
-	op.GeoM.Translate(s.X+s.Width/2, s.Y+s.Height/2)
+	op.GeoM.Translate(x+s.Width/2, y+s.Height/2)
+	op.GeoM.Concat(view)
 
 	if s.Opacity < 1.0 {
 		op.ColorScale.Scale(1, 1, 1, float32(s.Opacity))
 	}
 }
 
-func (s *Shape) drawBorder(screen *ebiten.Image) {
+func (s *Shape) drawBorder(screen *ebiten.Image, view ebiten.GeoM) {
 
 	borderImg := ebiten.NewImage(int(s.Width+s.Border.Width*2), int(s.Height+s.Border.Width*2))
 	borderImg.Fill(s.Border.Background)
 
+	x, y, _ := s.renderState()
+
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(s.X-s.Border.Width, s.Y-s.Border.Width)
+	op.GeoM.Translate(x-s.Border.Width, y-s.Border.Width)
+	op.GeoM.Concat(view)
 	screen.DrawImage(borderImg, op)
 }
 
@@ -583,16 +638,61 @@ func (s *Shape) IsOutOfMap() bool {
 }
 
 func (s *Shape) SetProps(props map[string]interface{}) {
-
+	for property, value := range props {
+		s.Set(property, value)
+	}
 }
 
+// Get returns the shape field with the given name, such as "ZIndex", or else
+// the custom property stored under it by Set.
 func (s *Shape) Get(property string) interface{} {
-
-	return nil
+	if field := fieldByName(reflect.ValueOf(s).Elem(), property); field.IsValid() {
+		return field.Interface()
+	}
+	return s.properties[property]
 }
 
+// Set assigns the shape field with the given name, keeping the body in sync
+// for position and rotation. Names that are not fields are kept as custom
+// properties, readable through Get.
 func (s *Shape) Set(property string, value interface{}) {
+	if name, ok := value.(string); ok {
+		switch {
+		case strings.EqualFold(property, "Tag"):
+			s.SetTag(name)
+			return
+		case strings.EqualFold(property, "Name"):
+			s.SetName(name)
+			return
+		}
+	}
 
+	if s.Body != nil {
+		f, isNumber := toFloat(value)
+		switch {
+		case isNumber && strings.EqualFold(property, "X"):
+			s.SetX(f)
+			return
+		case isNumber && strings.EqualFold(property, "Y"):
+			s.SetY(f)
+			return
+		case isNumber && strings.EqualFold(property, "RotationAngle"):
+			s.SetRotation(f)
+			return
+		}
+	}
+
+	if field := fieldByName(reflect.ValueOf(s).Elem(), property); field.IsValid() {
+		if assignValue(field, value) {
+			s.cachedColorImage = nil
+		}
+		return
+	}
+
+	if s.properties == nil {
+		s.properties = make(map[string]interface{})
+	}
+	s.properties[property] = value
 }
 
 func (s *Shape) Move(direction string) {
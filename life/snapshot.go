@@ -0,0 +1,550 @@
+package life
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/ByteArena/box2d"
+)
+
+const SnapshotVersion = 2
+
+// Snapshot is a serializable copy of a world's state. Images, callbacks,
+// event handlers, and custom properties and component fields that are not
+// plain data cannot be serialized, so Restore keeps those from the live shape
+// with the same ID and leaves them empty on shapes it has to recreate.
+type Snapshot struct {
+	Version   int
+	Level     int
+	LevelSeed int64
+	Frame     int64
+	Settings  SnapshotSettings
+	Shapes    []ShapeSnapshot
+}
+
+type SnapshotSettings struct {
+	Width         int
+	Height        int
+	G             Vector2
+	AirResistance float64
+	Paused        bool
+	FixedStep     float64
+	MaxSubSteps   int
+	Background    *color.RGBA
+	Camera        *CameraSnapshot
+}
+
+type CameraSnapshot struct {
+	X, Y     float64
+	Zoom     float64
+	Rotation float64
+}
+
+type ShapeSnapshot struct {
+	ID            string
+	Name          string
+	Tag           string
+	Type          ShapeType
+	X, Y          float64
+	Width, Height float64
+	Radius        float64
+	RotationAngle float64
+	RotationLock  bool
+	ZIndex        int
+	Scale         float64
+	Opacity       float64
+	Pattern       PatternType
+	Background    *color.RGBA
+	Border        *BorderSnapshot
+	Flip          struct{ X, Y bool }
+
+	IsBody   bool
+	Physics  bool
+	Ghost    bool
+	Mass     float64
+	Speed    float64
+	Rebound  float64
+	Friction float64
+	Velocity Vector2
+
+	// Body state, in box2d units.
+	LinearVelocity  Vector2
+	AngularVelocity float64
+	FixedRotation   bool
+	Awake           bool
+	CategoryBits    uint16
+	MaskBits        uint16
+	GroupIndex      int16
+
+	NoCollideWith []string
+
+	// Properties are the shape's custom properties, such as those of Tiled
+	// objects, that hold plain data; see plainValue.
+	Properties map[string]interface{} `json:",omitempty"`
+
+	// Components hold the plain-data fields of the shape's components.
+	Components []ComponentSnapshot `json:",omitempty"`
+
+	// Chains are the outlines of merged map tiles, relative to the shape's
+	// top-left corner. Their bodies are rebuilt from them.
+	Chains [][]Vector2 `json:",omitempty"`
+}
+
+// ComponentSnapshot is a component by type name, such as "*life.Health",
+// with its exported fields that hold plain data, as JSON by field name.
+// Other fields keep their live values, or are zero on a recreated component.
+type ComponentSnapshot struct {
+	Type   string
+	Fields map[string]json.RawMessage `json:",omitempty"`
+}
+
+type BorderSnapshot struct {
+	Width      float64
+	Background *color.RGBA
+	Pattern    PatternType
+}
+
+// plainValue reports whether v holds only data that survives a trip through
+// JSON: booleans, numbers, strings, and slices, string-keyed maps, pointers
+// and structs of exported fields made of them.
+func plainValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil() || plainValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !plainValue(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if !plainValue(iter.Value()) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() || !plainValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func snapshotColor(c color.Color) *color.RGBA {
+	if c == nil {
+		return nil
+	}
+
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return &rgba
+}
+
+func restoreColor(c *color.RGBA) color.Color {
+	if c == nil {
+		return nil
+	}
+	return *c
+}
+
+// Snapshot captures every registered shape, the current level and the world
+// settings.
+func (w *World) Snapshot() *Snapshot {
+	snapshot := &Snapshot{
+		Version:   SnapshotVersion,
+		Level:     w.CurrentLevel,
+		LevelSeed: w.levelSeed,
+		Frame:     w.frame,
+		Settings: SnapshotSettings{
+			Width:         w.Width,
+			Height:        w.Height,
+			G:             w.G,
+			AirResistance: w.AirResistance,
+			Paused:        w.Paused,
+			FixedStep:     w.FixedStep,
+			MaxSubSteps:   w.MaxSubSteps,
+			Background:    snapshotColor(w.Background),
+		},
+	}
+
+	if w.Camera != nil {
+		snapshot.Settings.Camera = &CameraSnapshot{
+			X:        w.Camera.X,
+			Y:        w.Camera.Y,
+			Zoom:     w.Camera.Zoom,
+			Rotation: w.Camera.Rotation,
+		}
+	}
+
+	for _, obj := range w.GetAllElements() {
+		snapshot.Shapes = append(snapshot.Shapes, obj.snapshot())
+	}
+
+	return snapshot
+}
+
+func (s *Shape) snapshot() ShapeSnapshot {
+	snap := ShapeSnapshot{
+		ID:            s.ID,
+		Name:          s.Name,
+		Tag:           s.Tag,
+		Type:          s.Type,
+		X:             s.X,
+		Y:             s.Y,
+		Width:         s.Width,
+		Height:        s.Height,
+		Radius:        s.Radius,
+		RotationAngle: s.RotationAngle,
+		RotationLock:  s.RotationLock,
+		ZIndex:        s.ZIndex,
+		Scale:         s.Scale,
+		Opacity:       s.Opacity,
+		Pattern:       s.Pattern,
+		Background:    snapshotColor(s.Background),
+		Flip:          s.Flip,
+		IsBody:        s.IsBody,
+		Physics:       s.Physics,
+		Ghost:         s.Ghost,
+		Mass:          s.Mass,
+		Speed:         s.Speed,
+		Rebound:       s.Rebound,
+		Friction:      s.Friction,
+		Velocity:      s.Velocity,
+		Chains:        s.chains,
+	}
+
+	for name, value := range s.properties {
+		if plainValue(reflect.ValueOf(value)) {
+			if snap.Properties == nil {
+				snap.Properties = make(map[string]interface{})
+			}
+			snap.Properties[name] = value
+		}
+	}
+
+	if s.Border != nil {
+		snap.Border = &BorderSnapshot{
+			Width:      s.Border.Width,
+			Background: snapshotColor(s.Border.Background),
+			Pattern:    s.Border.Pattern,
+		}
+	}
+
+	if s.Body != nil {
+		velocity := s.Body.GetLinearVelocity()
+		snap.LinearVelocity = Vector2{X: velocity.X, Y: velocity.Y}
+		snap.AngularVelocity = s.Body.GetAngularVelocity()
+		snap.FixedRotation = s.Body.IsFixedRotation()
+		snap.Awake = s.Body.IsAwake()
+
+		if fixture := s.Body.GetFixtureList(); fixture != nil {
+			filter := fixture.GetFilterData()
+			snap.CategoryBits = filter.CategoryBits
+			snap.MaskBits = filter.MaskBits
+			snap.GroupIndex = filter.GroupIndex
+		}
+	}
+
+	for t, c := range s.components {
+		snap.Components = append(snap.Components, snapshotComponent(t, c))
+	}
+	sort.Slice(snap.Components, func(i, j int) bool {
+		return snap.Components[i].Type < snap.Components[j].Type
+	})
+
+	for id, excluded := range s.noCollideWith {
+		if excluded {
+			snap.NoCollideWith = append(snap.NoCollideWith, id)
+		}
+	}
+
+	return snap
+}
+
+// Restore brings the world back to the snapshot. When the snapshot was taken
+// in another level, or another mount of it, that level is mounted again with
+// the recorded seed first so its shapes get their original IDs back. Shapes
+// are then matched by ID and their bodies recreated.
+func (w *World) Restore(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("snapshot is nil")
+	}
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	if !w.levelLoaded || snapshot.Level != w.CurrentLevel || snapshot.LevelSeed != w.levelSeed {
+		if err := w.selectLevel(snapshot.Level, snapshot.LevelSeed); err != nil {
+			return fmt.Errorf("failed to restore level: %w", err)
+		}
+	}
+
+	settings := snapshot.Settings
+	w.Width = settings.Width
+	w.Height = settings.Height
+	w.G = settings.G
+	w.PhysicsWorld.SetGravity(box2dGravity(settings.G))
+	w.AirResistance = settings.AirResistance
+	w.Paused = settings.Paused
+	w.FixedStep = settings.FixedStep
+	w.MaxSubSteps = settings.MaxSubSteps
+	if settings.Background != nil {
+		w.Background = *settings.Background
+	}
+	if settings.Camera != nil && w.Camera != nil {
+		w.Camera.Zoom = settings.Camera.Zoom
+		w.Camera.Rotation = settings.Camera.Rotation
+		w.Camera.LookAt(settings.Camera.X, settings.Camera.Y)
+	}
+
+	w.mutex.Lock()
+
+	live := make(map[string]*Shape, len(w.Objects))
+	for _, obj := range w.Objects {
+		live[obj.ID] = obj
+	}
+
+	objects := make([]*Shape, 0, len(snapshot.Shapes))
+	for _, snap := range snapshot.Shapes {
+		obj, ok := live[snap.ID]
+		if ok {
+			delete(live, snap.ID)
+		} else {
+			obj = NewShape(&ShapeProps{Type: snap.Type})
+			obj.world = w
+		}
+
+		if obj.Body != nil {
+			w.PhysicsWorld.DestroyBody(obj.Body)
+			obj.Body = nil
+		}
+
+		obj.restore(snap)
+		w.createPhysicsBody(obj)
+		obj.restoreBody(snap)
+		obj.storePreviousState()
+
+		objects = append(objects, obj)
+	}
+
+	for _, obj := range live {
+		if obj.Body != nil {
+			w.PhysicsWorld.DestroyBody(obj.Body)
+			obj.Body = nil
+		}
+		obj.world = nil
+	}
+
+	w.Objects = objects
+	w.index.reset(objects)
+	w.mutex.Unlock()
+
+	w.accumulator = 0
+	w.frame = snapshot.Frame
+
+	return nil
+}
+
+func (s *Shape) restore(snap ShapeSnapshot) {
+	s.ID = snap.ID
+	s.Name = snap.Name
+	s.Tag = snap.Tag
+	s.Type = snap.Type
+	s.X = snap.X
+	s.Y = snap.Y
+	s.Width = snap.Width
+	s.Height = snap.Height
+	s.Radius = snap.Radius
+	s.RotationAngle = snap.RotationAngle
+	s.RotationLock = snap.RotationLock
+	s.ZIndex = snap.ZIndex
+	s.Scale = snap.Scale
+	s.Opacity = snap.Opacity
+	s.Pattern = snap.Pattern
+	if snap.Background != nil {
+		s.SetBackground(*snap.Background)
+	}
+	s.Flip = snap.Flip
+	s.IsBody = snap.IsBody
+	s.Physics = snap.Physics
+	s.Ghost = snap.Ghost
+	s.Mass = snap.Mass
+	s.Speed = snap.Speed
+	s.Rebound = snap.Rebound
+	s.Friction = snap.Friction
+	s.Velocity = snap.Velocity
+	s.chains = snap.Chains
+
+	// Properties that could not be saved are kept from the live shape.
+	for name, value := range s.properties {
+		if plainValue(reflect.ValueOf(value)) {
+			delete(s.properties, name)
+		}
+	}
+	for name, value := range snap.Properties {
+		if s.properties == nil {
+			s.properties = make(map[string]interface{}, len(snap.Properties))
+		}
+		s.properties[name] = value
+	}
+
+	s.Border = nil
+	if snap.Border != nil {
+		s.Border = &Border{
+			Width:      snap.Border.Width,
+			Background: restoreColor(snap.Border.Background),
+			Pattern:    snap.Border.Pattern,
+		}
+	}
+
+	s.noCollideWith = make(map[string]bool, len(snap.NoCollideWith))
+	for _, id := range snap.NoCollideWith {
+		s.noCollideWith[id] = true
+	}
+
+	s.restoreComponents(snap.Components)
+
+	// Contacts are reported again by box2d once the new bodies touch.
+	s.CollisionObjects = nil
+}
+
+func (s *Shape) restoreBody(snap ShapeSnapshot) {
+	s.Body.SetTransform(s.Body.GetPosition(), snap.RotationAngle)
+	s.Body.SetFixedRotation(snap.FixedRotation)
+	s.Body.SetLinearVelocity(box2d.MakeB2Vec2(snap.LinearVelocity.X, snap.LinearVelocity.Y))
+	s.Body.SetAngularVelocity(snap.AngularVelocity)
+	s.Body.SetAwake(snap.Awake)
+
+	for fixture := s.Body.GetFixtureList(); fixture != nil; fixture = fixture.GetNext() {
+		filter := fixture.GetFilterData()
+		filter.CategoryBits = snap.CategoryBits
+		filter.MaskBits = snap.MaskBits
+		filter.GroupIndex = snap.GroupIndex
+		fixture.SetFilterData(filter)
+	}
+}
+
+func SaveSnapshot(path string, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot file %s: %w", path, err)
+	}
+	return nil
+}
+
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file %s: %w", path, err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot file %s: %w", path, err)
+	}
+
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s", snapshot.Version, path)
+	}
+	return &snapshot, nil
+}
+
+func snapshotComponent(t reflect.Type, c Component) ComponentSnapshot {
+	snap := ComponentSnapshot{Type: t.String()}
+
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return snap
+	}
+	v = v.Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || !plainValue(v.Field(i)) {
+			continue
+		}
+		data, err := json.Marshal(v.Field(i).Interface())
+		if err != nil {
+			continue
+		}
+		if snap.Fields == nil {
+			snap.Fields = make(map[string]json.RawMessage)
+		}
+		snap.Fields[field.Name] = data
+	}
+	return snap
+}
+
+// restoreComponents gives the shape the snapshot's components, reusing the
+// live ones of the same type and recreating the others, and removes the
+// components the snapshot does not have.
+func (s *Shape) restoreComponents(snaps []ComponentSnapshot) {
+	kept := make(map[reflect.Type]bool, len(snaps))
+	for _, snap := range snaps {
+		t := componentType(snap.Type)
+		if t == nil {
+			continue
+		}
+		kept[t] = true
+
+		c, ok := s.components[t]
+		if !ok {
+			if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			c = reflect.New(t.Elem()).Interface()
+			s.AddComponent(c)
+		}
+
+		v := reflect.ValueOf(c)
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		for name, data := range snap.Fields {
+			field := v.Elem().FieldByName(name)
+			if !field.IsValid() || !field.CanSet() {
+				continue
+			}
+			target := reflect.New(field.Type())
+			if err := json.Unmarshal(data, target.Interface()); err == nil {
+				field.Set(target.Elem())
+			}
+		}
+	}
+
+	for t, c := range s.components {
+		if !kept[t] {
+			s.RemoveComponent(c)
+		}
+	}
+
+	// Whether OnDeath ran is not saved. Snapshots are taken between steps,
+	// once the health system has handled any death, so it ran if the
+	// restored health is spent.
+	if h, ok := GetComponent[*Health](s); ok {
+		h.dead = h.IsDead()
+	}
+}
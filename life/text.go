@@ -1,22 +1,547 @@
 package life
 
 import (
+	"fmt"
 	"image/color"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+)
+
+// defaultFontSize is the size of basicfont.Face7x13, drawn when no font is
+// given. Sizes other than this scale the bitmap font.
+const defaultFontSize = 13
+
+type TextAlign int
+
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// VerticalAlign says which part of the text sits at its Y. The zero value
+// puts the first line's baseline there.
+type VerticalAlign int
+
+const (
+	AlignBaseline VerticalAlign = iota
+	AlignTop
+	AlignMiddle
+	AlignBottom
+)
+
+// Anchor is the point of the screen that a text's X and Y are measured from.
+// X grows inwards from right anchors and Y upwards from bottom ones.
+type Anchor int
+
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCenter
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
 )
 
 type TextProps struct {
-	Text    string
-	X, Y    float64
-	Color   color.Color
-	Font    font.Face
-	Size    float64
+	Text  string
+	X, Y  float64
+	Color color.Color
+
+	// Font is drawn as is. Without it, Type names a font registered with
+	// RegisterFont, drawn at Size.
+	Font font.Face
+	Size float64
+	Type string
+
+	// FromEnd measures X from the right edge of the screen and right-aligns
+	// the text, like AnchorTopRight with AlignRight.
 	FromEnd bool
-	Type    string
+
+	Align         TextAlign
+	VerticalAlign VerticalAlign
+	Anchor        Anchor
+
+	// MaxWidth wraps lines at word boundaries to fit, when positive. Words
+	// wider than MaxWidth are left on their own line. LineSpacing multiplies
+	// the font's line height and defaults to 1.
+	MaxWidth    float64
+	LineSpacing float64
+
+	OutlineColor color.Color
+	OutlineWidth float64
+	ShadowColor  color.Color
+	ShadowOffset Vector2
+
+	// Markup makes DrawText read tags in Text for colors, bold and italic,
+	// icons and per-character effects; see parseMarkup. Bold and italic use
+	// the fonts registered as Type plus "-bold", "-italic" or
+	// "-bold-italic", and are faked when there is none.
+	Markup bool
+
+	// Time drives the per-character effects. Pass the world's GameTime so
+	// they pause and slow down with the game.
+	Time time.Duration
+}
+
+// Font is a parsed TrueType or OpenType font, from which faces of any size
+// are made and cached.
+type Font struct {
+	font  *opentype.Font
+	faces map[float64]font.Face
+	mutex sync.Mutex
+}
+
+var (
+	fonts      = make(map[string]*Font)
+	fontsMutex sync.RWMutex
+)
+
+// ParseFont parses TTF or OTF data.
+func ParseFont(data []byte) (*Font, error) {
+	parsed, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	return &Font{font: parsed, faces: make(map[float64]font.Face)}, nil
+}
+
+// LoadFontFromFS reads and parses a TTF or OTF file from fsys.
+func LoadFontFromFS(fsys fs.FS, path string) (*Font, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+
+	f, err := ParseFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// LoadFont reads a TTF or OTF file from disk and returns its face at size.
+func LoadFont(path string, size float64) (font.Face, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+
+	f, err := ParseFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font %s: %w", path, err)
+	}
+	return f.Face(size)
+}
+
+// Face returns the font at size, in pixels. A size of zero or less is
+// defaultFontSize.
+func (f *Font) Face(size float64) (font.Face, error) {
+	if size <= 0 {
+		size = defaultFontSize
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if face, ok := f.faces[size]; ok {
+		return face, nil
+	}
+
+	face, err := opentype.NewFace(f.font, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face of size %v: %w", size, err)
+	}
+	f.faces[size] = face
+	return face, nil
+}
+
+// RegisterFont makes the font available to TextProps.Type by name. The
+// font named "default" is used for texts without a Type.
+func RegisterFont(name string, f *Font) {
+	fontsMutex.Lock()
+	defer fontsMutex.Unlock()
+	fonts[name] = f
+}
+
+func GetFont(name string) *Font {
+	fontsMutex.RLock()
+	defer fontsMutex.RUnlock()
+	return fonts[name]
+}
+
+// face returns the face to draw with and how much to scale it by, which is
+// only ever not 1 for the bitmap fallback.
+func (props *TextProps) face() (font.Face, float64) {
+	if props.Font != nil {
+		return props.Font, 1
+	}
+
+	name := props.Type
+	if name == "" {
+		name = "default"
+	}
+	if f := GetFont(name); f != nil {
+		face, err := f.Face(props.Size)
+		if err == nil {
+			return face, 1
+		}
+		log.Print(err)
+	}
+
+	if props.Size > 0 {
+		return basicfont.Face7x13, props.Size / defaultFontSize
+	}
+	return basicfont.Face7x13, 1
+}
+
+func measureLine(face font.Face, line string) float64 {
+	return float64(font.MeasureString(face, line)) / 64
+}
+
+// styledFace returns the face for a bold or italic span, and whether the
+// style has to be faked because no variant of the font is registered.
+func (props *TextProps) styledFace(bold, italic bool) (face font.Face, scale float64, fauxBold, fauxItalic bool) {
+	if (bold || italic) && props.Font == nil {
+		name := props.Type
+		if name == "" {
+			name = "default"
+		}
+
+		switch {
+		case bold && italic:
+			name += "-bold-italic"
+		case bold:
+			name += "-bold"
+		default:
+			name += "-italic"
+		}
+		if f := GetFont(name); f != nil {
+			if face, err := f.Face(props.Size); err == nil {
+				return face, 1, false, false
+			}
+		}
+	}
+
+	face, scale = props.face()
+	return face, scale, bold, italic
+}
+
+// textRun is a span, or the part of one between spaces, placed on a line.
+type textRun struct {
+	textSpan
+	x, width   float64
+	face       font.Face
+	scale      float64
+	fauxBold   bool
+	fauxItalic bool
+
+	// index is the position of the run's first character in the text, so
+	// effects move neighbouring characters differently.
+	index int
+	space bool
+	br    bool
+}
+
+type textLine struct {
+	runs  []textRun
+	width float64
+}
+
+type textLayout struct {
+	lines      []textLine
+	width      float64
+	height     float64
+	ascent     float64
+	lineHeight float64
+}
+
+// tokens splits the spans into words, spaces and line breaks, measured in
+// their faces.
+func (props *TextProps) tokens(spans []textSpan, ascent float64) []textRun {
+	var tokens []textRun
+	index := 0
+
+	for _, span := range spans {
+		face, scale, fauxBold, fauxItalic := props.styledFace(span.bold, span.italic)
+		base := textRun{textSpan: span, face: face, scale: scale, fauxBold: fauxBold, fauxItalic: fauxItalic}
+
+		if span.icon != nil {
+			run := base
+			bounds := span.icon.Bounds()
+			run.width = float64(bounds.Dx()) * ascent / float64(bounds.Dy())
+			run.index = index
+			index++
+			tokens = append(tokens, run)
+			continue
+		}
+
+		for i, paragraph := range strings.Split(span.text, "\n") {
+			if i > 0 {
+				tokens = append(tokens, textRun{br: true})
+				index++
+			}
+
+			for len(paragraph) > 0 {
+				space := paragraph[0] == ' '
+				n := strings.IndexFunc(paragraph, func(r rune) bool { return (r == ' ') != space })
+				if n < 0 {
+					n = len(paragraph)
+				}
+
+				run := base
+				run.text = paragraph[:n]
+				run.space = space
+				run.index = index
+				run.width = measureLine(face, run.text) * scale
+				if fauxBold {
+					run.width++
+				}
+				tokens = append(tokens, run)
+
+				index += utf8.RuneCountInString(run.text)
+				paragraph = paragraph[n:]
+			}
+		}
+	}
+	return tokens
+}
+
+func (props *TextProps) layout() *textLayout {
+	face, scale := props.face()
+	metrics := face.Metrics()
+
+	spacing := props.LineSpacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+
+	l := &textLayout{
+		lines:      []textLine{{}},
+		ascent:     float64(metrics.Ascent) / 64 * scale,
+		lineHeight: float64(metrics.Height) / 64 * scale * spacing,
+	}
+
+	spans := []textSpan{{text: props.Text}}
+	if props.Markup {
+		spans = parseMarkup(props.Text)
+	}
+
+	// Lines break at spaces only, so runs between spaces, which may come
+	// from different spans, are placed together as a word.
+	var spaces, word []textRun
+	placeWord := func() {
+		if len(word) == 0 {
+			return
+		}
+
+		var spacesWidth, wordWidth float64
+		for _, run := range spaces {
+			spacesWidth += run.width
+		}
+		for _, run := range word {
+			wordWidth += run.width
+		}
+
+		line := &l.lines[len(l.lines)-1]
+		if props.MaxWidth > 0 && len(line.runs) > 0 && line.width+spacesWidth+wordWidth > props.MaxWidth {
+			l.lines = append(l.lines, textLine{})
+			line = &l.lines[len(l.lines)-1]
+			spaces = nil
+		}
+
+		for _, run := range append(spaces, word...) {
+			run.x = line.width
+			line.runs = append(line.runs, run)
+			line.width += run.width
+		}
+		spaces, word = nil, nil
+	}
+
+	// Spaces ending a line only take room when lines are not wrapped, so
+	// text fields can put their cursor after them.
+	placeTrailingSpaces := func() {
+		if props.MaxWidth <= 0 {
+			line := &l.lines[len(l.lines)-1]
+			for _, run := range spaces {
+				run.x = line.width
+				line.runs = append(line.runs, run)
+				line.width += run.width
+			}
+		}
+		spaces = nil
+	}
+
+	for _, token := range props.tokens(spans, l.ascent) {
+		switch {
+		case token.br:
+			placeWord()
+			placeTrailingSpaces()
+			l.lines = append(l.lines, textLine{})
+		case token.space:
+			placeWord()
+			spaces = append(spaces, token)
+		default:
+			word = append(word, token)
+		}
+	}
+	placeWord()
+	placeTrailingSpaces()
+
+	for _, line := range l.lines {
+		l.width = math.Max(l.width, line.width)
+	}
+	textHeight := float64(metrics.Ascent+metrics.Descent) / 64 * scale
+	l.height = l.lineHeight*float64(len(l.lines)-1) + textHeight
+	return l
+}
+
+// MeasureText returns the width and height, in pixels, of the text props
+// would draw, including wrapping and line spacing.
+func MeasureText(props *TextProps) (width, height float64) {
+	if props == nil || props.Text == "" {
+		return 0, 0
+	}
+	l := props.layout()
+	return l.width, l.height
+}
+
+// origin returns the top left corner of the text block on a screen of the
+// given size.
+func (props *TextProps) origin(l *textLayout, screenWidth, screenHeight float64) (float64, float64) {
+	anchor, align := props.Anchor, props.Align
+	if props.FromEnd && anchor == AnchorTopLeft {
+		anchor, align = AnchorTopRight, AlignRight
+	}
+
+	x, y := props.X, props.Y
+	switch anchor {
+	case AnchorTop, AnchorCenter, AnchorBottom:
+		x = screenWidth/2 + props.X
+	case AnchorTopRight, AnchorRight, AnchorBottomRight:
+		x = screenWidth - props.X
+	}
+	switch anchor {
+	case AnchorLeft, AnchorCenter, AnchorRight:
+		y = screenHeight/2 + props.Y
+	case AnchorBottomLeft, AnchorBottom, AnchorBottomRight:
+		y = screenHeight - props.Y
+	}
+
+	switch align {
+	case AlignCenter:
+		x -= l.width / 2
+	case AlignRight:
+		x -= l.width
+	}
+
+	switch props.VerticalAlign {
+	case AlignBaseline:
+		y -= l.ascent
+	case AlignMiddle:
+		y -= l.height / 2
+	case AlignBottom:
+		y -= l.height
+	}
+	return x, y
+}
+
+// draw draws the lines with their top left corner at x, y. Runs are drawn
+// in their own color unless override is set, which also tints icons, for
+// outlines and shadows.
+func (l *textLayout) draw(screen *ebiten.Image, x, y float64, align TextAlign, c color.Color, override bool, t float64) {
+	op := &ebiten.DrawImageOptions{}
+	for i, line := range l.lines {
+		lineX := x
+		switch align {
+		case AlignCenter:
+			lineX += (l.width - line.width) / 2
+		case AlignRight:
+			lineX += l.width - line.width
+		}
+		baseline := y + l.ascent + l.lineHeight*float64(i)
+
+		for _, run := range line.runs {
+			if run.space {
+				continue
+			}
+
+			runColor := c
+			if !override && run.color != nil {
+				runColor = run.color
+			}
+
+			if run.icon != nil {
+				bounds := run.icon.Bounds()
+				iconScale := l.ascent / float64(bounds.Dy())
+				dx, dy := effectOffset(run.effect, run.index, t, l.lineHeight)
+
+				op.GeoM.Reset()
+				op.GeoM.Scale(iconScale, iconScale)
+				op.GeoM.Translate(math.Round(lineX+run.x+dx), math.Round(baseline-l.ascent+dy))
+				op.ColorScale.Reset()
+				if override {
+					op.ColorScale.ScaleWithColor(runColor)
+				}
+				op.Filter = ebiten.FilterLinear
+				screen.DrawImage(run.icon, op)
+				op.Filter = ebiten.FilterNearest
+				continue
+			}
+
+			if run.effect == "" {
+				l.drawString(screen, op, run, run.text, lineX+run.x, baseline, runColor)
+				continue
+			}
+
+			// Characters with an effect are drawn one by one.
+			charX := lineX + run.x
+			index := run.index
+			for _, r := range run.text {
+				char := string(r)
+				dx, dy := effectOffset(run.effect, index, t, l.lineHeight)
+				l.drawString(screen, op, run, char, charX+dx, baseline+dy, runColor)
+				charX += measureLine(run.face, char) * run.scale
+				index++
+			}
+		}
+	}
+}
+
+func (l *textLayout) drawString(screen *ebiten.Image, op *ebiten.DrawImageOptions, run textRun, s string, x, baseline float64, c color.Color) {
+	op.GeoM.Reset()
+	op.GeoM.Scale(run.scale, run.scale)
+	if run.fauxItalic {
+		op.GeoM.Skew(-0.2, 0)
+	}
+	op.GeoM.Translate(math.Round(x), math.Round(baseline))
+	op.ColorScale.Reset()
+	op.ColorScale.ScaleWithColor(c)
+	text.DrawWithOptions(screen, s, run.face, op)
+
+	if run.fauxBold {
+		op.GeoM.Translate(1, 0)
+		text.DrawWithOptions(screen, s, run.face, op)
+	}
 }
 
 func DrawText(screen *ebiten.Image, props *TextProps) {
@@ -24,9 +549,6 @@ func DrawText(screen *ebiten.Image, props *TextProps) {
 		return
 	}
 
-	if props.Font == nil {
-		props.Font = basicfont.Face7x13
-	}
 	if props.Color == nil {
 		props.Color = color.RGBA{255, 255, 255, 255}
 	}
@@ -34,19 +556,32 @@ func DrawText(screen *ebiten.Image, props *TextProps) {
 		return
 	}
 
-	x := int(props.X)
-	y := int(props.Y)
+	l := props.layout()
+	bounds := screen.Bounds()
+	x, y := props.origin(l, float64(bounds.Dx()), float64(bounds.Dy()))
 
-	if props.FromEnd {
-
-		bounds, _ := font.BoundString(props.Font, props.Text)
-		x = int(float64(screen.Bounds().Dx()) - float64(bounds.Max.X) - props.X)
+	align := props.Align
+	if props.FromEnd && props.Anchor == AnchorTopLeft {
+		align = AlignRight
 	}
 
-	text.Draw(screen, props.Text, props.Font, x, y, props.Color)
-}
+	t := props.Time.Seconds()
 
-func LoadFont(path string, size float64) (font.Face, error) {
+	if props.ShadowColor != nil {
+		l.draw(screen, x+props.ShadowOffset.X, y+props.ShadowOffset.Y, align, props.ShadowColor, true, t)
+	}
+
+	if props.OutlineColor != nil && props.OutlineWidth > 0 {
+		// The outline is the text drawn around a circle of the outline's
+		// width, with more copies for wider outlines so it stays closed.
+		steps := int(math.Max(8, math.Ceil(props.OutlineWidth*2*math.Pi)))
+		for i := 0; i < steps; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(steps)
+			dx := math.Cos(angle) * props.OutlineWidth
+			dy := math.Sin(angle) * props.OutlineWidth
+			l.draw(screen, x+dx, y+dy, align, props.OutlineColor, true, t)
+		}
+	}
 
-	return basicfont.Face7x13, nil
+	l.draw(screen, x, y, align, props.Color, false, t)
 }
@@ -0,0 +1,1060 @@
+package life
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	TiledTileLayer   = "tilelayer"
+	TiledObjectGroup = "objectgroup"
+	TiledGroupLayer  = "group"
+	TiledImageLayer  = "imagelayer"
+)
+
+// Tile GIDs keep their flip flags in the top bits.
+const (
+	tiledFlipHorizontal uint32 = 0x80000000
+	tiledFlipVertical   uint32 = 0x40000000
+	tiledFlipDiagonal   uint32 = 0x20000000
+	tiledRotateHex      uint32 = 0x10000000
+	tiledGIDMask               = ^(tiledFlipHorizontal | tiledFlipVertical | tiledFlipDiagonal | tiledRotateHex)
+)
+
+// TiledMap is an orthogonal map made in the Tiled editor, loaded from a .tmx
+// or .tmj file together with its tilesets and their images.
+type TiledMap struct {
+	Width, Height         int
+	TileWidth, TileHeight int
+	Layers                []*TiledLayer
+	Tilesets              []*TiledTileset
+	Properties            map[string]interface{}
+}
+
+type TiledLayer struct {
+	Name    string
+	Type    string
+	Class   string
+	Visible bool
+	Opacity float64
+	Offset  Vector2
+
+	// Tiles holds the GIDs of a tile layer row by row, flip flags included.
+	// Zero is an empty cell.
+	Width, Height int
+	Tiles         []uint32
+
+	Objects []*TiledObject
+	Layers  []*TiledLayer
+
+	Properties map[string]interface{}
+}
+
+type TiledTileset struct {
+	FirstGID              int
+	Name                  string
+	TileWidth, TileHeight int
+	TileCount, Columns    int
+	Spacing, Margin       int
+	Image                 *ebiten.Image
+	Tiles                 map[int]*TiledTile
+	Properties            map[string]interface{}
+
+	images map[int]*ebiten.Image
+}
+
+// TiledTile holds what the tileset says about one of its tiles. Objects are
+// the tile's collision shapes, relative to its top-left corner.
+type TiledTile struct {
+	ID         int
+	Type       string
+	Image      *ebiten.Image
+	Objects    []*TiledObject
+	Properties map[string]interface{}
+}
+
+// TiledObject is an object from an object layer. Position is the top-left
+// corner of its bounding box, also for tile objects and polygons, whose
+// points are relative to Position.
+type TiledObject struct {
+	ID            int
+	Name          string
+	Type          string
+	Position      Vector2
+	Width, Height float64
+	Rotation      float64
+	Visible       bool
+	Ellipse       bool
+	Point         bool
+	Polygon       []Vector2
+	Properties    map[string]interface{}
+
+	// Image and Flip are set on tile objects.
+	GID   uint32
+	Image *ebiten.Image
+	Flip  struct{ X, Y bool }
+}
+
+// LoadTiledMap reads a Tiled map from fsys. Files ending in .tmx are read as
+// XML and anything else as JSON. External tilesets and images are resolved
+// relative to the file that references them.
+func LoadTiledMap(fsys fs.FS, filePath string) (*TiledMap, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tiled map %s: %w", filePath, err)
+	}
+
+	tiled, err := parseTiledMap(fsys, filePath, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tiled map %s: %w", filePath, err)
+	}
+	return tiled, nil
+}
+
+func isTiledFile(filePath string) bool {
+	ext := strings.ToLower(path.Ext(filePath))
+	return ext == ".tmx" || ext == ".tmj"
+}
+
+func parseTiledMap(fsys fs.FS, filePath string, data []byte) (*TiledMap, error) {
+	var (
+		tiled *TiledMap
+		err   error
+	)
+	if strings.EqualFold(path.Ext(filePath), ".tmx") {
+		tiled, err = parseTMX(fsys, filePath, data)
+	} else {
+		tiled, err = parseTMJ(fsys, filePath, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tiled.resolveTileObjects(tiled.Layers)
+	return tiled, nil
+}
+
+// Tileset returns the tileset a GID belongs to and the tile's local ID.
+func (m *TiledMap) Tileset(gid uint32) (*TiledTileset, int) {
+	gid &= tiledGIDMask
+	if gid == 0 {
+		return nil, 0
+	}
+
+	var found *TiledTileset
+	for _, tileset := range m.Tilesets {
+		if uint32(tileset.FirstGID) <= gid && (found == nil || tileset.FirstGID > found.FirstGID) {
+			found = tileset
+		}
+	}
+	if found == nil {
+		return nil, 0
+	}
+	return found, int(gid) - found.FirstGID
+}
+
+// TileImage returns the image of a tile by its local ID.
+func (t *TiledTileset) TileImage(id int) *ebiten.Image {
+	if img, ok := t.images[id]; ok {
+		return img
+	}
+
+	var img *ebiten.Image
+	if tile, ok := t.Tiles[id]; ok && tile.Image != nil {
+		img = tile.Image
+	} else if t.Image != nil && t.Columns > 0 && id >= 0 {
+		x := t.Margin + (id%t.Columns)*(t.TileWidth+t.Spacing)
+		y := t.Margin + (id/t.Columns)*(t.TileHeight+t.Spacing)
+		img = t.Image.SubImage(image.Rect(x, y, x+t.TileWidth, y+t.TileHeight)).(*ebiten.Image)
+	}
+
+	if t.images == nil {
+		t.images = make(map[int]*ebiten.Image)
+	}
+	t.images[id] = img
+	return img
+}
+
+// resolveTileObjects gives tile objects their image, and the tile's type
+// and properties where the object does not override them.
+func (m *TiledMap) resolveTileObjects(layers []*TiledLayer) {
+	for _, layer := range layers {
+		m.resolveTileObjects(layer.Layers)
+
+		for _, obj := range layer.Objects {
+			if obj.GID == 0 {
+				continue
+			}
+
+			obj.Flip.X = obj.GID&tiledFlipHorizontal != 0
+			obj.Flip.Y = obj.GID&tiledFlipVertical != 0
+
+			tileset, id := m.Tileset(obj.GID)
+			if tileset == nil {
+				continue
+			}
+			obj.Image = tileset.TileImage(id)
+
+			if tile, ok := tileset.Tiles[id]; ok {
+				if obj.Type == "" {
+					obj.Type = tile.Type
+				}
+				obj.Properties = mergeProperties(tile.Properties, obj.Properties)
+			}
+		}
+	}
+}
+
+func mergeProperties(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+func tiledProperty(name, kind string, value interface{}) (interface{}, error) {
+	switch kind {
+	case "int", "object":
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("property %s: %v is not an int", name, value)
+		}
+		return int(f), nil
+	case "float":
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("property %s: %v is not a float", name, value)
+		}
+		return f, nil
+	case "bool":
+		switch b := value.(type) {
+		case bool:
+			return b, nil
+		case string:
+			return b == "true", nil
+		}
+		return nil, fmt.Errorf("property %s: %v is not a bool", name, value)
+	case "color":
+		s, _ := value.(string)
+		if s == "" {
+			return nil, nil
+		}
+		c, err := ParseHexColor(s)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		return c, nil
+	}
+	return value, nil
+}
+
+func loadTiledImage(fsys fs.FS, baseFile, source string) (*ebiten.Image, error) {
+	if source == "" {
+		return nil, nil
+	}
+
+	imagePath := path.Join(path.Dir(baseFile), source)
+	img, err := LoadImageFromFS(fsys, imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tileset image %s: %w", imagePath, err)
+	}
+	return img, nil
+}
+
+// decodeTileData decodes base64 tile data, optionally compressed, into GIDs.
+func decodeTileData(text, compression string) ([]uint32, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 tile data: %w", err)
+	}
+
+	var reader io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "zlib":
+		if reader, err = zlib.NewReader(reader); err != nil {
+			return nil, fmt.Errorf("invalid zlib tile data: %w", err)
+		}
+	case "gzip":
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, fmt.Errorf("invalid gzip tile data: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tile data compression %q", compression)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress tile data: %w", err)
+	}
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("tile data length %d is not a multiple of 4", len(data))
+	}
+
+	gids := make([]uint32, len(data)/4)
+	for i := range gids {
+		gids[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+	return gids, nil
+}
+
+func decodeCSVTileData(text string) ([]uint32, error) {
+	var gids []uint32
+	for _, field := range strings.Split(text, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		gid, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv tile data %q", field)
+		}
+		gids = append(gids, uint32(gid))
+	}
+	return gids, nil
+}
+
+// finishObject moves tile objects and polygons so Position is the top-left
+// corner of their bounding box.
+func finishObject(obj *TiledObject) {
+	if obj.GID != 0 {
+		obj.Position.Y -= obj.Height
+		return
+	}
+
+	if len(obj.Polygon) == 0 {
+		return
+	}
+
+	lo, hi := obj.Polygon[0], obj.Polygon[0]
+	for _, p := range obj.Polygon[1:] {
+		lo.X = min(lo.X, p.X)
+		lo.Y = min(lo.Y, p.Y)
+		hi.X = max(hi.X, p.X)
+		hi.Y = max(hi.Y, p.Y)
+	}
+
+	for i := range obj.Polygon {
+		obj.Polygon[i] = obj.Polygon[i].Sub(lo)
+	}
+	obj.Position = obj.Position.Add(lo)
+	obj.Width = hi.X - lo.X
+	obj.Height = hi.Y - lo.Y
+}
+
+// TMX (XML) format.
+
+type tmxMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Infinite    int           `xml:"infinite,attr"`
+	Properties  tmxProperties `xml:"properties"`
+	Tilesets    []tmxTileset  `xml:"tileset"`
+	Layers      []tmxLayer    `xml:",any"`
+}
+
+type tmxProperties struct {
+	Property []tmxProperty `xml:"property"`
+}
+
+type tmxProperty struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Value      *string       `xml:"value,attr"`
+	Text       string        `xml:",chardata"`
+	Properties tmxProperties `xml:"properties"`
+}
+
+type tmxTileset struct {
+	FirstGID   int           `xml:"firstgid,attr"`
+	Source     string        `xml:"source,attr"`
+	Name       string        `xml:"name,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Columns    int           `xml:"columns,attr"`
+	Spacing    int           `xml:"spacing,attr"`
+	Margin     int           `xml:"margin,attr"`
+	Image      *tmxImage     `xml:"image"`
+	Tiles      []tmxTile     `xml:"tile"`
+	Properties tmxProperties `xml:"properties"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+}
+
+type tmxTile struct {
+	ID          int           `xml:"id,attr"`
+	Type        string        `xml:"type,attr"`
+	Class       string        `xml:"class,attr"`
+	Image       *tmxImage     `xml:"image"`
+	ObjectGroup *tmxLayer     `xml:"objectgroup"`
+	Properties  tmxProperties `xml:"properties"`
+}
+
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Class      string        `xml:"class,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Opacity    *float64      `xml:"opacity,attr"`
+	OffsetX    float64       `xml:"offsetx,attr"`
+	OffsetY    float64       `xml:"offsety,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Data       *tmxData      `xml:"data"`
+	Objects    []tmxObject   `xml:"object"`
+	Layers     []tmxLayer    `xml:",any"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+	Tiles       []struct {
+		GID uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+	Chunks []struct{} `xml:"chunk"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Rotation   float64       `xml:"rotation,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Point      *struct{}     `xml:"point"`
+	Polygon    *tmxPoints    `xml:"polygon"`
+	Polyline   *tmxPoints    `xml:"polyline"`
+	Properties tmxProperties `xml:"properties"`
+}
+
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+func parseTMX(fsys fs.FS, filePath string, data []byte) (*TiledMap, error) {
+	var raw tmxMap
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if raw.Orientation != "" && raw.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("unsupported orientation %q", raw.Orientation)
+	}
+	if raw.Infinite != 0 {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+
+	properties, err := raw.Properties.convert()
+	if err != nil {
+		return nil, err
+	}
+
+	tiled := &TiledMap{
+		Width:      raw.Width,
+		Height:     raw.Height,
+		TileWidth:  raw.TileWidth,
+		TileHeight: raw.TileHeight,
+		Properties: properties,
+	}
+
+	for _, rawTileset := range raw.Tilesets {
+		tileset, err := loadTMXTileset(fsys, filePath, rawTileset)
+		if err != nil {
+			return nil, err
+		}
+		tiled.Tilesets = append(tiled.Tilesets, tileset)
+	}
+
+	tiled.Layers, err = convertTMXLayers(raw.Layers)
+	if err != nil {
+		return nil, err
+	}
+	return tiled, nil
+}
+
+func (p tmxProperties) convert() (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, len(p.Property))
+	for _, prop := range p.Property {
+		if prop.Type == "class" {
+			nested, err := prop.Properties.convert()
+			if err != nil {
+				return nil, err
+			}
+			properties[prop.Name] = nested
+			continue
+		}
+
+		value := prop.Text
+		if prop.Value != nil {
+			value = *prop.Value
+		}
+
+		converted, err := tiledProperty(prop.Name, prop.Type, value)
+		if err != nil {
+			return nil, err
+		}
+		properties[prop.Name] = converted
+	}
+	return properties, nil
+}
+
+func loadTMXTileset(fsys fs.FS, filePath string, raw tmxTileset) (*TiledTileset, error) {
+	firstGID := raw.FirstGID
+	tilesetPath := filePath
+
+	if raw.Source != "" {
+		tilesetPath = path.Join(path.Dir(filePath), raw.Source)
+		data, err := fs.ReadFile(fsys, tilesetPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tileset %s: %w", tilesetPath, err)
+		}
+
+		if !strings.EqualFold(path.Ext(tilesetPath), ".tsx") {
+			tileset, err := parseTMJTileset(fsys, tilesetPath, data)
+			if err != nil {
+				return nil, err
+			}
+			tileset.FirstGID = firstGID
+			return tileset, nil
+		}
+
+		raw = tmxTileset{}
+		if err := xml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse tileset %s: %w", tilesetPath, err)
+		}
+	}
+
+	properties, err := raw.Properties.convert()
+	if err != nil {
+		return nil, err
+	}
+
+	tileset := &TiledTileset{
+		FirstGID:   firstGID,
+		Name:       raw.Name,
+		TileWidth:  raw.TileWidth,
+		TileHeight: raw.TileHeight,
+		TileCount:  raw.TileCount,
+		Columns:    raw.Columns,
+		Spacing:    raw.Spacing,
+		Margin:     raw.Margin,
+		Tiles:      make(map[int]*TiledTile),
+		Properties: properties,
+	}
+
+	if raw.Image != nil {
+		if tileset.Image, err = loadTiledImage(fsys, tilesetPath, raw.Image.Source); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rawTile := range raw.Tiles {
+		tile := &TiledTile{
+			ID:   rawTile.ID,
+			Type: rawTile.Type,
+		}
+		if tile.Type == "" {
+			tile.Type = rawTile.Class
+		}
+
+		if tile.Properties, err = rawTile.Properties.convert(); err != nil {
+			return nil, err
+		}
+
+		if rawTile.Image != nil {
+			if tile.Image, err = loadTiledImage(fsys, tilesetPath, rawTile.Image.Source); err != nil {
+				return nil, err
+			}
+		}
+
+		if rawTile.ObjectGroup != nil {
+			for _, rawObject := range rawTile.ObjectGroup.Objects {
+				obj, err := rawObject.convert()
+				if err != nil {
+					return nil, err
+				}
+				tile.Objects = append(tile.Objects, obj)
+			}
+		}
+
+		tileset.Tiles[tile.ID] = tile
+	}
+
+	return tileset, nil
+}
+
+func convertTMXLayers(rawLayers []tmxLayer) ([]*TiledLayer, error) {
+	var layers []*TiledLayer
+
+	for _, raw := range rawLayers {
+		layer := &TiledLayer{
+			Name:    raw.Name,
+			Class:   raw.Class,
+			Visible: raw.Visible == nil || *raw.Visible != 0,
+			Opacity: 1,
+			Offset:  Vector2{X: raw.OffsetX, Y: raw.OffsetY},
+			Width:   raw.Width,
+			Height:  raw.Height,
+		}
+		if raw.Opacity != nil {
+			layer.Opacity = *raw.Opacity
+		}
+
+		var err error
+		if layer.Properties, err = raw.Properties.convert(); err != nil {
+			return nil, err
+		}
+
+		switch raw.XMLName.Local {
+		case "layer":
+			layer.Type = TiledTileLayer
+			if layer.Tiles, err = raw.Data.decode(); err != nil {
+				return nil, fmt.Errorf("layer %q: %w", raw.Name, err)
+			}
+		case "objectgroup":
+			layer.Type = TiledObjectGroup
+			for _, rawObject := range raw.Objects {
+				obj, err := rawObject.convert()
+				if err != nil {
+					return nil, fmt.Errorf("layer %q: %w", raw.Name, err)
+				}
+				layer.Objects = append(layer.Objects, obj)
+			}
+		case "group":
+			layer.Type = TiledGroupLayer
+			if layer.Layers, err = convertTMXLayers(raw.Layers); err != nil {
+				return nil, err
+			}
+		case "imagelayer":
+			layer.Type = TiledImageLayer
+		default:
+			continue
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+func (d *tmxData) decode() ([]uint32, error) {
+	if d == nil {
+		return nil, nil
+	}
+	if len(d.Chunks) > 0 {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+
+	switch d.Encoding {
+	case "csv":
+		return decodeCSVTileData(d.Text)
+	case "base64":
+		return decodeTileData(d.Text, d.Compression)
+	case "":
+		gids := make([]uint32, len(d.Tiles))
+		for i, tile := range d.Tiles {
+			gids[i] = tile.GID
+		}
+		return gids, nil
+	}
+	return nil, fmt.Errorf("unsupported tile data encoding %q", d.Encoding)
+}
+
+func (raw tmxObject) convert() (*TiledObject, error) {
+	properties, err := raw.Properties.convert()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &TiledObject{
+		ID:         raw.ID,
+		Name:       raw.Name,
+		Type:       raw.Type,
+		Position:   Vector2{X: raw.X, Y: raw.Y},
+		Width:      raw.Width,
+		Height:     raw.Height,
+		Rotation:   raw.Rotation,
+		Visible:    raw.Visible == nil || *raw.Visible != 0,
+		Ellipse:    raw.Ellipse != nil,
+		Point:      raw.Point != nil,
+		GID:        raw.GID,
+		Properties: properties,
+	}
+	if obj.Type == "" {
+		obj.Type = raw.Class
+	}
+
+	points := raw.Polygon
+	if points == nil {
+		points = raw.Polyline
+	}
+	if points != nil {
+		for _, pair := range strings.Fields(points.Points) {
+			xs, ys, ok := strings.Cut(pair, ",")
+			x, errX := strconv.ParseFloat(xs, 64)
+			y, errY := strconv.ParseFloat(ys, 64)
+			if !ok || errX != nil || errY != nil {
+				return nil, fmt.Errorf("object %d: invalid point %q", raw.ID, pair)
+			}
+			obj.Polygon = append(obj.Polygon, Vector2{X: x, Y: y})
+		}
+	}
+
+	finishObject(obj)
+	return obj, nil
+}
+
+// TMJ (JSON) format.
+
+type tmjMap struct {
+	Orientation string        `json:"orientation"`
+	Width       int           `json:"width"`
+	Height      int           `json:"height"`
+	TileWidth   int           `json:"tilewidth"`
+	TileHeight  int           `json:"tileheight"`
+	Infinite    bool          `json:"infinite"`
+	Layers      []tmjLayer    `json:"layers"`
+	Tilesets    []tmjTileset  `json:"tilesets"`
+	Properties  []tmjProperty `json:"properties"`
+}
+
+type tmjProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Class       string          `json:"class"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Visible     *bool           `json:"visible"`
+	Opacity     *float64        `json:"opacity"`
+	OffsetX     float64         `json:"offsetx"`
+	OffsetY     float64         `json:"offsety"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Objects     []tmjObject     `json:"objects"`
+	Layers      []tmjLayer      `json:"layers"`
+	Properties  []tmjProperty   `json:"properties"`
+}
+
+type tmjTileset struct {
+	FirstGID   int           `json:"firstgid"`
+	Source     string        `json:"source"`
+	Name       string        `json:"name"`
+	TileWidth  int           `json:"tilewidth"`
+	TileHeight int           `json:"tileheight"`
+	TileCount  int           `json:"tilecount"`
+	Columns    int           `json:"columns"`
+	Spacing    int           `json:"spacing"`
+	Margin     int           `json:"margin"`
+	Image      string        `json:"image"`
+	Tiles      []tmjTile     `json:"tiles"`
+	Properties []tmjProperty `json:"properties"`
+}
+
+type tmjTile struct {
+	ID          int           `json:"id"`
+	Type        string        `json:"type"`
+	Class       string        `json:"class"`
+	Image       string        `json:"image"`
+	ObjectGroup *tmjLayer     `json:"objectgroup"`
+	Properties  []tmjProperty `json:"properties"`
+}
+
+type tmjObject struct {
+	ID         int           `json:"id"`
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Class      string        `json:"class"`
+	X          float64       `json:"x"`
+	Y          float64       `json:"y"`
+	Width      float64       `json:"width"`
+	Height     float64       `json:"height"`
+	Rotation   float64       `json:"rotation"`
+	GID        uint32        `json:"gid"`
+	Visible    *bool         `json:"visible"`
+	Ellipse    bool          `json:"ellipse"`
+	Point      bool          `json:"point"`
+	Polygon    []Vector2     `json:"polygon"`
+	Polyline   []Vector2     `json:"polyline"`
+	Properties []tmjProperty `json:"properties"`
+}
+
+func parseTMJ(fsys fs.FS, filePath string, data []byte) (*TiledMap, error) {
+	var raw tmjMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if raw.Orientation != "" && raw.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("unsupported orientation %q", raw.Orientation)
+	}
+	if raw.Infinite {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+
+	properties, err := convertTMJProperties(raw.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	tiled := &TiledMap{
+		Width:      raw.Width,
+		Height:     raw.Height,
+		TileWidth:  raw.TileWidth,
+		TileHeight: raw.TileHeight,
+		Properties: properties,
+	}
+
+	for _, rawTileset := range raw.Tilesets {
+		var tileset *TiledTileset
+
+		if rawTileset.Source != "" {
+			tilesetPath := path.Join(path.Dir(filePath), rawTileset.Source)
+			tilesetData, err := fs.ReadFile(fsys, tilesetPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tileset %s: %w", tilesetPath, err)
+			}
+
+			if strings.EqualFold(path.Ext(tilesetPath), ".tsx") {
+				var tsx tmxTileset
+				if err := xml.Unmarshal(tilesetData, &tsx); err != nil {
+					return nil, fmt.Errorf("failed to parse tileset %s: %w", tilesetPath, err)
+				}
+				tileset, err = loadTMXTileset(fsys, tilesetPath, tsx)
+			} else {
+				tileset, err = parseTMJTileset(fsys, tilesetPath, tilesetData)
+			}
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			if tileset, err = convertTMJTileset(fsys, filePath, rawTileset); err != nil {
+				return nil, err
+			}
+		}
+
+		tileset.FirstGID = rawTileset.FirstGID
+		tiled.Tilesets = append(tiled.Tilesets, tileset)
+	}
+
+	if tiled.Layers, err = convertTMJLayers(raw.Layers); err != nil {
+		return nil, err
+	}
+	return tiled, nil
+}
+
+func parseTMJTileset(fsys fs.FS, tilesetPath string, data []byte) (*TiledTileset, error) {
+	var raw tmjTileset
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse tileset %s: %w", tilesetPath, err)
+	}
+	return convertTMJTileset(fsys, tilesetPath, raw)
+}
+
+func convertTMJProperties(raw []tmjProperty) (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, len(raw))
+	for _, prop := range raw {
+		value, err := tiledProperty(prop.Name, prop.Type, prop.Value)
+		if err != nil {
+			return nil, err
+		}
+		properties[prop.Name] = value
+	}
+	return properties, nil
+}
+
+func convertTMJTileset(fsys fs.FS, tilesetPath string, raw tmjTileset) (*TiledTileset, error) {
+	properties, err := convertTMJProperties(raw.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	tileset := &TiledTileset{
+		Name:       raw.Name,
+		TileWidth:  raw.TileWidth,
+		TileHeight: raw.TileHeight,
+		TileCount:  raw.TileCount,
+		Columns:    raw.Columns,
+		Spacing:    raw.Spacing,
+		Margin:     raw.Margin,
+		Tiles:      make(map[int]*TiledTile),
+		Properties: properties,
+	}
+
+	if tileset.Image, err = loadTiledImage(fsys, tilesetPath, raw.Image); err != nil {
+		return nil, err
+	}
+
+	for _, rawTile := range raw.Tiles {
+		tile := &TiledTile{
+			ID:   rawTile.ID,
+			Type: rawTile.Type,
+		}
+		if tile.Type == "" {
+			tile.Type = rawTile.Class
+		}
+
+		if tile.Properties, err = convertTMJProperties(rawTile.Properties); err != nil {
+			return nil, err
+		}
+		if tile.Image, err = loadTiledImage(fsys, tilesetPath, rawTile.Image); err != nil {
+			return nil, err
+		}
+
+		if rawTile.ObjectGroup != nil {
+			for _, rawObject := range rawTile.ObjectGroup.Objects {
+				obj, err := rawObject.convert()
+				if err != nil {
+					return nil, err
+				}
+				tile.Objects = append(tile.Objects, obj)
+			}
+		}
+
+		tileset.Tiles[tile.ID] = tile
+	}
+
+	return tileset, nil
+}
+
+func convertTMJLayers(rawLayers []tmjLayer) ([]*TiledLayer, error) {
+	var layers []*TiledLayer
+
+	for _, raw := range rawLayers {
+		layer := &TiledLayer{
+			Name:    raw.Name,
+			Type:    raw.Type,
+			Class:   raw.Class,
+			Visible: raw.Visible == nil || *raw.Visible,
+			Opacity: 1,
+			Offset:  Vector2{X: raw.OffsetX, Y: raw.OffsetY},
+			Width:   raw.Width,
+			Height:  raw.Height,
+		}
+		if raw.Opacity != nil {
+			layer.Opacity = *raw.Opacity
+		}
+
+		var err error
+		if layer.Properties, err = convertTMJProperties(raw.Properties); err != nil {
+			return nil, err
+		}
+
+		switch raw.Type {
+		case TiledTileLayer:
+			if layer.Tiles, err = raw.decode(); err != nil {
+				return nil, fmt.Errorf("layer %q: %w", raw.Name, err)
+			}
+		case TiledObjectGroup:
+			for _, rawObject := range raw.Objects {
+				obj, err := rawObject.convert()
+				if err != nil {
+					return nil, fmt.Errorf("layer %q: %w", raw.Name, err)
+				}
+				layer.Objects = append(layer.Objects, obj)
+			}
+		case TiledGroupLayer:
+			if layer.Layers, err = convertTMJLayers(raw.Layers); err != nil {
+				return nil, err
+			}
+		case TiledImageLayer:
+		default:
+			continue
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+func (l *tmjLayer) decode() ([]uint32, error) {
+	if len(l.Data) == 0 {
+		return nil, nil
+	}
+
+	if l.Encoding == "base64" {
+		var text string
+		if err := json.Unmarshal(l.Data, &text); err != nil {
+			return nil, fmt.Errorf("invalid base64 tile data: %w", err)
+		}
+		return decodeTileData(text, l.Compression)
+	}
+
+	var gids []uint32
+	if err := json.Unmarshal(l.Data, &gids); err != nil {
+		return nil, fmt.Errorf("invalid tile data: %w", err)
+	}
+	return gids, nil
+}
+
+func (raw tmjObject) convert() (*TiledObject, error) {
+	properties, err := convertTMJProperties(raw.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &TiledObject{
+		ID:         raw.ID,
+		Name:       raw.Name,
+		Type:       raw.Type,
+		Position:   Vector2{X: raw.X, Y: raw.Y},
+		Width:      raw.Width,
+		Height:     raw.Height,
+		Rotation:   raw.Rotation,
+		Visible:    raw.Visible == nil || *raw.Visible,
+		Ellipse:    raw.Ellipse,
+		Point:      raw.Point,
+		GID:        raw.GID,
+		Polygon:    raw.Polygon,
+		Properties: properties,
+	}
+	if obj.Type == "" {
+		obj.Type = raw.Class
+	}
+	if obj.Polygon == nil {
+		obj.Polygon = raw.Polyline
+	}
+
+	finishObject(obj)
+	return obj, nil
+}
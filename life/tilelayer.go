@@ -0,0 +1,331 @@
+package life
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TiledItems maps Tiled object types to callbacks, the way MapItems maps map
+// characters. Objects whose type has no callback are spawned with
+// SpawnTiledObject.
+type TiledItems map[string]func(object *TiledObject)
+
+// TileLayer draws a Tiled tile layer in world space. It is drawn before the
+// shapes whose ZIndex is equal or higher, taken from the layer's "ZIndex"
+// property.
+type TileLayer struct {
+	Name    string
+	ZIndex  int
+	Visible bool
+	Opacity float64
+	Offset  Vector2
+
+	// Effect, when set, draws the whole layer through a Kage shader.
+	Effect *Effect
+
+	world     *World
+	tiled     *TiledMap
+	layer     *TiledLayer
+	offscreen *ebiten.Image
+}
+
+// GenerateLevelFromTiled builds a level from a Tiled map: tile layers are
+// drawn with their tilesets, tiles with collision shapes in the tileset get
+// static bodies, and objects are passed to items by type.
+func (w *World) GenerateLevelFromTiled(tiled *TiledMap, items TiledItems) {
+	if tiled == nil {
+		return
+	}
+
+	w.generatingMap = true
+	defer func() { w.generatingMap = false }()
+
+	w.TileLayers = nil
+	w.generateTiledLayers(tiled, tiled.Layers, items, Vector2{}, 1, true)
+}
+
+func (w *World) generateTiledLayers(tiled *TiledMap, layers []*TiledLayer, items TiledItems, offset Vector2, opacity float64, visible bool) {
+	for _, layer := range layers {
+		layerOffset := offset.Add(layer.Offset)
+		layerOpacity := opacity * layer.Opacity
+		layerVisible := visible && layer.Visible
+
+		switch layer.Type {
+		case TiledTileLayer:
+			zIndex, _ := toFloat(layer.Properties["ZIndex"])
+			w.TileLayers = append(w.TileLayers, &TileLayer{
+				Name:    layer.Name,
+				ZIndex:  int(zIndex),
+				Visible: layerVisible,
+				Opacity: layerOpacity,
+				Offset:  layerOffset,
+				world:   w,
+				tiled:   tiled,
+				layer:   layer,
+			})
+			w.generateTileCollisions(tiled, layer, layerOffset)
+
+		case TiledObjectGroup:
+			for _, obj := range layer.Objects {
+				object := *obj
+				object.Position = object.Position.Add(layerOffset)
+				object.Visible = object.Visible && layerVisible
+
+				if fn, ok := items[object.Type]; ok {
+					fn(&object)
+				} else if !object.Point {
+					w.SpawnTiledObject(&object)
+				}
+			}
+
+		case TiledGroupLayer:
+			w.generateTiledLayers(tiled, layer.Layers, items, layerOffset, layerOpacity, layerVisible)
+		}
+	}
+}
+
+// SpawnTiledObject registers a static shape for a Tiled object. Its name and
+// type become the shape's Name and Tag, custom properties that name a
+// ShapeProps field (such as "IsBody" or "Friction") set that field, and the
+// rest are kept on the shape for Shape.Get.
+func (w *World) SpawnTiledObject(object *TiledObject) *Shape {
+	props := &ShapeProps{
+		Type:   ShapeRectangle,
+		X:      object.Position.X,
+		Y:      object.Position.Y,
+		Width:  object.Width,
+		Height: object.Height,
+		Name:   object.Name,
+		Tag:    object.Type,
+	}
+	if object.Ellipse {
+		props.Type = ShapeCircle
+		props.Radius = math.Min(object.Width, object.Height) / 2
+	}
+	if object.Image != nil {
+		props.Pattern = PatternImage
+		props.Image = object.Image
+		props.Flip = object.Flip
+	}
+
+	rest := props.ApplyProperties(object.Properties)
+
+	shape := NewShape(props)
+	w.Register(shape)
+	shape.SetProps(rest)
+
+	if object.Rotation != 0 {
+		// Tiled rotations are in degrees, RotationAngle in radians.
+		shape.SetRotation(object.Rotation * Deg)
+	}
+	if !object.Visible {
+		shape.Opacity = 0
+	}
+	return shape
+}
+
+// generateTileCollisions registers invisible static shapes for the collision
+// objects of every tile in the layer. Polygons use their bounding box. The
+// shape's tag is the collision object's type, else the tile's type, else the
+// layer's name. Tiles with a single rectangle are merged as the world's
+// MergeTiles says.
+func (w *World) generateTileCollisions(tiled *TiledMap, layer *TiledLayer, offset Vector2) {
+	var grid *tileGrid
+	if w.MergeTiles != TileMergeNone && layer.Width > 0 {
+		grid = newTileGrid(layer.Width, (len(layer.Tiles)+layer.Width-1)/layer.Width)
+	}
+
+	for i, gid := range layer.Tiles {
+		tileset, id := tiled.Tileset(gid)
+		if tileset == nil {
+			continue
+		}
+		tile, ok := tileset.Tiles[id]
+		if !ok || len(tile.Objects) == 0 {
+			continue
+		}
+
+		col := i % layer.Width
+		row := i / layer.Width
+		cellX := offset.X + float64(col*tiled.TileWidth)
+		cellY := offset.Y + float64((row+1)*tiled.TileHeight-tileset.TileHeight)
+		tileWidth := float64(tileset.TileWidth)
+		tileHeight := float64(tileset.TileHeight)
+
+		for _, obj := range tile.Objects {
+			x, y := obj.Position.X, obj.Position.Y
+			width, height := obj.Width, obj.Height
+
+			if gid&tiledFlipDiagonal != 0 {
+				x, y = y, x
+				width, height = height, width
+			}
+			if gid&tiledFlipHorizontal != 0 {
+				x = tileWidth - x - width
+			}
+			if gid&tiledFlipVertical != 0 {
+				y = tileHeight - y - height
+			}
+
+			tag := obj.Type
+			if tag == "" {
+				tag = tile.Type
+			}
+			if tag == "" {
+				tag = layer.Name
+			}
+
+			object := &TiledObject{
+				Name:       obj.Name,
+				Type:       tag,
+				Position:   Vector2{X: cellX + x, Y: cellY + y},
+				Width:      width,
+				Height:     height,
+				Ellipse:    obj.Ellipse,
+				Properties: mergeProperties(tile.Properties, obj.Properties),
+			}
+			if object.Width <= 0 || object.Height <= 0 {
+				continue
+			}
+
+			shape := w.SpawnTiledObject(object)
+			if grid != nil && len(tile.Objects) == 1 && !obj.Ellipse && len(obj.Polygon) == 0 {
+				grid.set(col, row, "", shape)
+			}
+		}
+	}
+
+	if grid != nil {
+		w.mergeTiles(grid)
+	}
+}
+
+// visibleWorldRect returns the world rectangle covered by the screen.
+func (w *World) visibleWorldRect(screen *ebiten.Image) Rect {
+	bounds := screen.Bounds()
+	corners := [][2]float64{
+		{0, 0},
+		{float64(bounds.Dx()), 0},
+		{0, float64(bounds.Dy())},
+		{float64(bounds.Dx()), float64(bounds.Dy())},
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range corners {
+		x, y := w.ScreenToWorld(corner[0], corner[1])
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// sortedTileLayers returns the visible tile layers ordered by ZIndex, keeping
+// the map's order between layers with the same ZIndex.
+func (w *World) sortedTileLayers() []*TileLayer {
+	var layers []*TileLayer
+	for _, layer := range w.TileLayers {
+		if layer.Visible && layer.Opacity > 0 {
+			layers = append(layers, layer)
+		}
+	}
+
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].ZIndex < layers[j].ZIndex
+	})
+	return layers
+}
+
+// Draw draws the tiles of the layer that fall inside visible.
+func (l *TileLayer) Draw(screen *ebiten.Image, view ebiten.GeoM, visible Rect) {
+	if l.Effect == nil || l.Effect.Err() != nil {
+		l.drawTiles(screen, view, visible)
+		return
+	}
+
+	bounds := screen.Bounds()
+	if l.offscreen == nil || l.offscreen.Bounds().Size() != bounds.Size() {
+		l.offscreen = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+	}
+	l.offscreen.Clear()
+	l.drawTiles(l.offscreen, view, visible)
+
+	var time float64
+	if l.world != nil {
+		time = l.world.GameTime().Seconds()
+	}
+	if err := l.Effect.draw(screen, l.offscreen, ebiten.GeoM{}, ebiten.ColorScale{}, time); err != nil {
+		screen.DrawImage(l.offscreen, nil)
+	}
+}
+
+func (l *TileLayer) drawTiles(screen *ebiten.Image, view ebiten.GeoM, visible Rect) {
+	tiled, layer := l.tiled, l.layer
+	if layer.Width <= 0 || tiled.TileWidth <= 0 || tiled.TileHeight <= 0 {
+		return
+	}
+
+	// Tiles taller or wider than the grid reach into neighbouring cells, so
+	// look one extra cell around the visible area.
+	firstCol := int(math.Floor((visible.X-l.Offset.X)/float64(tiled.TileWidth))) - 1
+	lastCol := int(math.Ceil((visible.X+visible.Width-l.Offset.X)/float64(tiled.TileWidth))) + 1
+	firstRow := int(math.Floor((visible.Y-l.Offset.Y)/float64(tiled.TileHeight))) - 1
+	lastRow := int(math.Ceil((visible.Y+visible.Height-l.Offset.Y)/float64(tiled.TileHeight))) + 1
+
+	firstCol = max(firstCol, 0)
+	firstRow = max(firstRow, 0)
+	lastCol = min(lastCol, layer.Width-1)
+	lastRow = min(lastRow, layer.Height-1)
+
+	op := &ebiten.DrawImageOptions{}
+	if l.Opacity < 1 {
+		op.ColorScale.ScaleAlpha(float32(l.Opacity))
+	}
+
+	for row := firstRow; row <= lastRow; row++ {
+		for col := firstCol; col <= lastCol; col++ {
+			i := row*layer.Width + col
+			if i >= len(layer.Tiles) {
+				continue
+			}
+
+			gid := layer.Tiles[i]
+			tileset, id := tiled.Tileset(gid)
+			if tileset == nil {
+				continue
+			}
+			img := tileset.TileImage(id)
+			if img == nil {
+				continue
+			}
+
+			width := float64(img.Bounds().Dx())
+			height := float64(img.Bounds().Dy())
+
+			op.GeoM.Reset()
+			op.GeoM.Translate(-width/2, -height/2)
+			if gid&tiledFlipDiagonal != 0 {
+				op.GeoM.Rotate(math.Pi / 2)
+				op.GeoM.Scale(-1, 1)
+				width, height = height, width
+			}
+			if gid&tiledFlipHorizontal != 0 {
+				op.GeoM.Scale(-1, 1)
+			}
+			if gid&tiledFlipVertical != 0 {
+				op.GeoM.Scale(1, -1)
+			}
+
+			// Tiles are anchored to the bottom-left corner of their cell.
+			x := l.Offset.X + float64(col*tiled.TileWidth)
+			y := l.Offset.Y + float64((row+1)*tiled.TileHeight) - height
+			op.GeoM.Translate(x+width/2, y+height/2)
+			op.GeoM.Concat(view)
+
+			screen.DrawImage(img, op)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package life
+
+import (
+	"fmt"
+	"math"
+)
+
+// TileMerge says how GenerateLevelFromMap, and the tile collisions of Tiled
+// maps, combine neighbouring solid tiles into fewer physics bodies.
+type TileMerge string
+
+const (
+	// TileMergeNone gives every tile its own body.
+	TileMergeNone TileMerge = ""
+
+	// TileMergeRects combines rows and rectangles of tiles into single boxes.
+	TileMergeRects TileMerge = "rects"
+
+	// TileMergeChains outlines every connected group of tiles with box2d
+	// chain shapes, which bodies slide along without catching on corners.
+	TileMergeChains TileMerge = "chains"
+)
+
+const tileMergeEpsilon = 0.01
+
+// tileGrid holds, for every cell of a map, the single static rectangle its
+// callback registered, if it can be merged with its neighbours.
+type tileGrid struct {
+	cols, rows int
+	cells      []*Shape
+	keys       []string
+}
+
+func newTileGrid(cols, rows int) *tileGrid {
+	return &tileGrid{
+		cols:  cols,
+		rows:  rows,
+		cells: make([]*Shape, cols*rows),
+		keys:  make([]string, cols*rows),
+	}
+}
+
+// set records the shape created for a cell. Only static, solid, unrotated
+// rectangles are merged; group tells apart tiles that should never share a
+// body, such as different map characters.
+func (g *tileGrid) set(col, row int, group string, shape *Shape) {
+	if col < 0 || row < 0 || col >= g.cols || row >= g.rows {
+		return
+	}
+	if shape.IsBody || shape.Ghost || shape.RotationAngle != 0 || shape.Body == nil {
+		return
+	}
+	if shape.Type != ShapeRectangle && shape.Type != ShapeSquare {
+		return
+	}
+	for _, excluded := range shape.noCollideWith {
+		if excluded {
+			return
+		}
+	}
+
+	i := row*g.cols + col
+	g.cells[i] = shape
+	g.keys[i] = fmt.Sprintf("%s|%s|%v|%v|%v", group, shape.Tag, shape.Friction, shape.Rebound, shape.properties)
+}
+
+func (g *tileGrid) at(col, row int) (*Shape, string) {
+	if col < 0 || row < 0 || col >= g.cols || row >= g.rows {
+		return nil, ""
+	}
+	i := row*g.cols + col
+	return g.cells[i], g.keys[i]
+}
+
+// joinsRight reports whether the tile at (col, row) and the one right of it
+// can share a body: same group and properties, same height, and touching or
+// overlapping.
+func (g *tileGrid) joinsRight(col, row int) bool {
+	a, keyA := g.at(col, row)
+	b, keyB := g.at(col+1, row)
+	if a == nil || b == nil || keyA != keyB {
+		return false
+	}
+	return math.Abs(a.Y-b.Y) < tileMergeEpsilon &&
+		math.Abs(a.Height-b.Height) < tileMergeEpsilon &&
+		b.X > a.X && b.X <= a.X+a.Width+tileMergeEpsilon
+}
+
+// joinsDown is joinsRight for the tile below.
+func (g *tileGrid) joinsDown(col, row int) bool {
+	a, keyA := g.at(col, row)
+	b, keyB := g.at(col, row+1)
+	if a == nil || b == nil || keyA != keyB {
+		return false
+	}
+	return math.Abs(a.X-b.X) < tileMergeEpsilon &&
+		math.Abs(a.Width-b.Width) < tileMergeEpsilon &&
+		b.Y > a.Y && b.Y <= a.Y+a.Height+tileMergeEpsilon
+}
+
+// cellRect returns the part of the tile at (col, row) that its right and
+// lower neighbours do not cover, so overlapping tiles outline cleanly.
+func (g *tileGrid) cellRect(col, row int) (topLeft, bottomRight Vector2) {
+	s, _ := g.at(col, row)
+	topLeft = Vector2{X: s.X, Y: s.Y}
+	bottomRight = Vector2{X: s.X + s.Width, Y: s.Y + s.Height}
+
+	if g.joinsRight(col, row) {
+		right, _ := g.at(col+1, row)
+		bottomRight.X = right.X
+	}
+	if g.joinsDown(col, row) {
+		below, _ := g.at(col, row+1)
+		bottomRight.Y = below.Y
+	}
+	return topLeft, bottomRight
+}
+
+func (w *World) mergeTiles(grid *tileGrid) {
+	switch w.MergeTiles {
+	case TileMergeRects:
+		w.mergeTileRects(grid)
+	case TileMergeChains:
+		w.mergeTileChains(grid)
+	}
+}
+
+// mergeTileRects greedily grows rectangles, first along each row and then
+// down, and gives each rectangle of more than one tile a single box.
+func (w *World) mergeTileRects(grid *tileGrid) {
+	used := make([]bool, len(grid.cells))
+
+	for row := 0; row < grid.rows; row++ {
+		for col := 0; col < grid.cols; col++ {
+			if grid.cells[row*grid.cols+col] == nil || used[row*grid.cols+col] {
+				continue
+			}
+
+			width := 1
+			for grid.joinsRight(col+width-1, row) && !used[row*grid.cols+col+width] {
+				width++
+			}
+
+			height := 1
+		grow:
+			for row+height < grid.rows {
+				for i := 0; i < width; i++ {
+					if !grid.joinsDown(col+i, row+height-1) || used[(row+height)*grid.cols+col+i] {
+						break grow
+					}
+					if i > 0 && !grid.joinsRight(col+i-1, row+height) {
+						break grow
+					}
+				}
+				height++
+			}
+
+			var members []*Shape
+			for y := row; y < row+height; y++ {
+				for x := col; x < col+width; x++ {
+					used[y*grid.cols+x] = true
+					members = append(members, grid.cells[y*grid.cols+x])
+				}
+			}
+
+			if len(members) > 1 {
+				w.replaceWithCollider(members, nil)
+			}
+		}
+	}
+}
+
+type tilePoint struct{ x, y int64 }
+
+func quantize(v Vector2) tilePoint {
+	return tilePoint{int64(math.Round(v.X * 1000)), int64(math.Round(v.Y * 1000))}
+}
+
+type tileEdge struct {
+	from, to Vector2
+	used     bool
+}
+
+// mergeTileChains outlines every connected group of joined tiles. Edges
+// between two tiles of the group are dropped and the remaining edges are
+// linked into loops, one per outline or hole.
+func (w *World) mergeTileChains(grid *tileGrid) {
+	visited := make([]bool, len(grid.cells))
+
+	for start := range grid.cells {
+		if grid.cells[start] == nil || visited[start] {
+			continue
+		}
+
+		var members []int
+		stack := []int{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			members = append(members, i)
+
+			col, row := i%grid.cols, i/grid.cols
+			neighbours := []struct {
+				col, row int
+				joined   bool
+			}{
+				{col + 1, row, grid.joinsRight(col, row)},
+				{col - 1, row, grid.joinsRight(col-1, row)},
+				{col, row + 1, grid.joinsDown(col, row)},
+				{col, row - 1, grid.joinsDown(col, row-1)},
+			}
+			for _, n := range neighbours {
+				j := n.row*grid.cols + n.col
+				if n.joined && !visited[j] {
+					visited[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+
+		if len(members) < 2 {
+			continue
+		}
+
+		var edges []*tileEdge
+		shapes := make([]*Shape, 0, len(members))
+		for _, i := range members {
+			col, row := i%grid.cols, i/grid.cols
+			shapes = append(shapes, grid.cells[i])
+
+			topLeft, bottomRight := grid.cellRect(col, row)
+			topRight := Vector2{X: bottomRight.X, Y: topLeft.Y}
+			bottomLeft := Vector2{X: topLeft.X, Y: bottomRight.Y}
+
+			if !grid.joinsDown(col, row-1) {
+				edges = append(edges, &tileEdge{from: topLeft, to: topRight})
+			}
+			if !grid.joinsRight(col, row) {
+				edges = append(edges, &tileEdge{from: topRight, to: bottomRight})
+			}
+			if !grid.joinsDown(col, row) {
+				edges = append(edges, &tileEdge{from: bottomRight, to: bottomLeft})
+			}
+			if !grid.joinsRight(col-1, row) {
+				edges = append(edges, &tileEdge{from: bottomLeft, to: topLeft})
+			}
+		}
+
+		w.replaceWithCollider(shapes, linkEdges(edges))
+	}
+}
+
+// linkEdges joins directed edges end to start into closed loops, dropping
+// the points in the middle of straight runs.
+func linkEdges(edges []*tileEdge) [][]Vector2 {
+	outgoing := make(map[tilePoint][]*tileEdge)
+	for _, edge := range edges {
+		key := quantize(edge.from)
+		outgoing[key] = append(outgoing[key], edge)
+	}
+
+	next := func(p Vector2) *tileEdge {
+		for _, edge := range outgoing[quantize(p)] {
+			if !edge.used {
+				return edge
+			}
+		}
+		return nil
+	}
+
+	var loops [][]Vector2
+	for _, first := range edges {
+		if first.used {
+			continue
+		}
+
+		var loop []Vector2
+		for edge := first; edge != nil; edge = next(edge.to) {
+			edge.used = true
+			loop = append(loop, edge.from)
+		}
+
+		if loop = simplifyLoop(loop); len(loop) >= 3 {
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
+
+func simplifyLoop(loop []Vector2) []Vector2 {
+	var result []Vector2
+	n := len(loop)
+	for i, p := range loop {
+		prev := loop[(i+n-1)%n]
+		next := loop[(i+1)%n]
+
+		cross := (p.X-prev.X)*(next.Y-p.Y) - (p.Y-prev.Y)*(next.X-p.X)
+		if math.Abs(cross) > tileMergeEpsilon {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// replaceWithCollider removes the bodies of the given tiles, keeps drawing
+// the tiles, and registers one invisible static shape over their bounds that
+// collides for all of them. It takes its tag, name, physics properties and
+// callbacks from the first tile. With chains, the collider's body is made of
+// those loops, given relative to its top-left corner, instead of a box.
+func (w *World) replaceWithCollider(tiles []*Shape, chains [][]Vector2) *Shape {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, tile := range tiles {
+		minX = math.Min(minX, tile.X)
+		minY = math.Min(minY, tile.Y)
+		maxX = math.Max(maxX, tile.X+tile.Width)
+		maxY = math.Max(maxY, tile.Y+tile.Height)
+	}
+
+	first := tiles[0]
+	collider := NewShape(&ShapeProps{
+		Type:                  ShapeRectangle,
+		X:                     minX,
+		Y:                     minY,
+		Width:                 maxX - minX,
+		Height:                maxY - minY,
+		Name:                  first.Name,
+		Tag:                   first.Tag,
+		Friction:              first.Friction,
+		Rebound:               first.Rebound,
+		RotationLock:          first.RotationLock,
+		OnCollisionFunc:       first.OnCollisionFunc,
+		OnFinishCollisionFunc: first.OnFinishCollisionFunc,
+	})
+	collider.Opacity = 0
+	collider.properties = first.properties
+
+	for _, loop := range chains {
+		relative := make([]Vector2, len(loop))
+		for i, p := range loop {
+			relative[i] = Vector2{X: p.X - minX, Y: p.Y - minY}
+		}
+		collider.chains = append(collider.chains, relative)
+	}
+
+	w.unregisterTiles(tiles)
+	w.Register(collider)
+	return collider
+}
+
+// unregisterTiles removes tiles from the world's objects and destroys their
+// bodies, but keeps drawing the visible ones.
+func (w *World) unregisterTiles(tiles []*Shape) {
+	removed := make(map[*Shape]bool, len(tiles))
+	for _, tile := range tiles {
+		removed[tile] = true
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	objects := w.Objects[:0]
+	for _, obj := range w.Objects {
+		if !removed[obj] {
+			objects = append(objects, obj)
+		}
+	}
+	for i := len(objects); i < len(w.Objects); i++ {
+		w.Objects[i] = nil
+	}
+	w.Objects = objects
+
+	for _, tile := range tiles {
+		w.index.remove(tile)
+		if tile.Body != nil {
+			w.PhysicsWorld.DestroyBody(tile.Body)
+			tile.Body = nil
+		}
+		if tile.Opacity > 0 {
+			w.mapTiles = append(w.mapTiles, tile)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package life
+
+import (
+	"sort"
+	"time"
+)
+
+// Timer is a callback scheduled on the world's game clock with After or
+// Every. The game clock only runs while the level ticks, so timers freeze
+// while the world is paused, a scene pauses the level or a level loads, and
+// it runs TimeScale times as fast as real time. Timers and tweens are
+// cancelled when the level is unloaded.
+type Timer struct {
+	due      float64
+	interval float64
+	repeat   bool
+	fn       func()
+	order    int
+	active   bool
+
+	// lastStep is the timer step the timer was scheduled or last fired in.
+	// It does not fire again in that step.
+	lastStep int
+}
+
+// Cancel stops the timer. Cancelling a timer that already fired or was
+// cancelled does nothing.
+func (t *Timer) Cancel() {
+	t.active = false
+}
+
+// Active reports whether the timer will still fire.
+func (t *Timer) Active() bool {
+	return t.active
+}
+
+// After calls fn once, d of game time from now.
+func (w *World) After(d time.Duration, fn func()) *Timer {
+	return w.schedule(d, fn, false)
+}
+
+// Every calls fn every d of game time until the timer is cancelled. A zero
+// or negative d calls fn on every step.
+func (w *World) Every(d time.Duration, fn func()) *Timer {
+	return w.schedule(d, fn, true)
+}
+
+func (w *World) schedule(d time.Duration, fn func(), repeat bool) *Timer {
+	w.timerCount++
+	timer := &Timer{
+		due:      w.clock + d.Seconds(),
+		interval: d.Seconds(),
+		repeat:   repeat,
+		fn:       fn,
+		order:    w.timerCount,
+		active:   true,
+		lastStep: w.timerStep,
+	}
+	w.timers = append(w.timers, timer)
+	return timer
+}
+
+// GameTime returns how much game time has passed since the world was
+// created.
+func (w *World) GameTime() time.Duration {
+	return time.Duration(w.clock * float64(time.Second))
+}
+
+// updateTimers advances the game clock by one scaled step and fires the
+// timers that came due, earliest first. A repeating timer that fell more
+// than one interval behind fires once per interval missed. Timers scheduled
+// by the callbacks wait for the next step, so a timer that re-arms itself
+// with After(0, ...) fires once per step instead of looping forever.
+func (w *World) updateTimers(delta float64) {
+	w.clock += delta
+	w.timerStep++
+
+	for {
+		var due []*Timer
+		for _, timer := range w.timers {
+			if timer.active && timer.due <= w.clock && timer.lastStep != w.timerStep {
+				due = append(due, timer)
+			}
+		}
+		if len(due) == 0 {
+			break
+		}
+
+		sort.Slice(due, func(i, j int) bool {
+			if due[i].due != due[j].due {
+				return due[i].due < due[j].due
+			}
+			return due[i].order < due[j].order
+		})
+
+		for _, timer := range due {
+			if !timer.active {
+				continue
+			}
+
+			switch {
+			case !timer.repeat:
+				timer.active = false
+			case timer.interval > 0:
+				timer.due += timer.interval
+			default:
+				timer.lastStep = w.timerStep
+			}
+
+			if timer.fn != nil {
+				timer.fn()
+			}
+		}
+	}
+
+	timers := w.timers[:0]
+	for _, timer := range w.timers {
+		if timer.active {
+			timers = append(timers, timer)
+		}
+	}
+	for i := len(timers); i < len(w.timers); i++ {
+		w.timers[i] = nil
+	}
+	w.timers = timers
+}
+
+// clearTimers cancels every timer.
+func (w *World) clearTimers() {
+	for _, timer := range w.timers {
+		timer.active = false
+	}
+	w.timers = nil
+}
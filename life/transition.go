@@ -0,0 +1,282 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Transition covers the screen while a level switch is in progress.
+type Transition interface {
+	// Duration is how long covering takes, and again how long uncovering
+	// takes once the next level is mounted.
+	Duration() time.Duration
+
+	// Draw covers the screen by progress, from 0 (nothing) to 1 (fully
+	// covered). entering is false while the outgoing level is covered and true
+	// while the incoming level is revealed.
+	Draw(screen *ebiten.Image, progress float64, entering bool)
+}
+
+type TransitionProps struct {
+	Duration time.Duration
+	Color    color.Color
+	Vertical bool
+}
+
+func transitionDefaults(props *TransitionProps) TransitionProps {
+	if props == nil {
+		props = &TransitionProps{}
+	}
+
+	result := *props
+	if result.Duration == 0 {
+		result.Duration = 400 * time.Millisecond
+	}
+	if result.Color == nil {
+		result.Color = color.RGBA{0, 0, 0, 255}
+	}
+	return result
+}
+
+type FadeTransition struct {
+	props TransitionProps
+}
+
+func NewFadeTransition(props *TransitionProps) *FadeTransition {
+	return &FadeTransition{props: transitionDefaults(props)}
+}
+
+func (t *FadeTransition) Duration() time.Duration {
+	return t.props.Duration
+}
+
+func (t *FadeTransition) Draw(screen *ebiten.Image, progress float64, entering bool) {
+	bounds := screen.Bounds()
+	vector.DrawFilledRect(screen, 0, 0, float32(bounds.Dx()), float32(bounds.Dy()), scaleAlpha(t.props.Color, progress), false)
+}
+
+// WipeTransition sweeps a solid bar across the screen, left to right (or top
+// to bottom when Vertical is set), and keeps sweeping the same way to reveal.
+type WipeTransition struct {
+	props TransitionProps
+}
+
+func NewWipeTransition(props *TransitionProps) *WipeTransition {
+	return &WipeTransition{props: transitionDefaults(props)}
+}
+
+func (t *WipeTransition) Duration() time.Duration {
+	return t.props.Duration
+}
+
+func (t *WipeTransition) Draw(screen *ebiten.Image, progress float64, entering bool) {
+	bounds := screen.Bounds()
+	width := float32(bounds.Dx())
+	height := float32(bounds.Dy())
+
+	if t.props.Vertical {
+		covered := height * float32(progress)
+		y := float32(0)
+		if entering {
+			y = height - covered
+		}
+		vector.DrawFilledRect(screen, 0, y, width, covered, t.props.Color, false)
+		return
+	}
+
+	covered := width * float32(progress)
+	x := float32(0)
+	if entering {
+		x = width - covered
+	}
+	vector.DrawFilledRect(screen, x, 0, covered, height, t.props.Color, false)
+}
+
+// IrisTransition closes a circle onto the center of the screen and opens it
+// again.
+type IrisTransition struct {
+	props TransitionProps
+}
+
+func NewIrisTransition(props *TransitionProps) *IrisTransition {
+	return &IrisTransition{props: transitionDefaults(props)}
+}
+
+func (t *IrisTransition) Duration() time.Duration {
+	return t.props.Duration
+}
+
+func (t *IrisTransition) Draw(screen *ebiten.Image, progress float64, entering bool) {
+	bounds := screen.Bounds()
+	cx := float64(bounds.Dx()) / 2
+	cy := float64(bounds.Dy()) / 2
+	outer := math.Hypot(cx, cy) + 1
+
+	if progress >= 1 {
+		vector.DrawFilledRect(screen, 0, 0, float32(bounds.Dx()), float32(bounds.Dy()), t.props.Color, false)
+		return
+	}
+
+	// A ring from the iris edge to past the screen corners covers everything
+	// outside the opening.
+	inner := outer * (1 - progress)
+	ringWidth := outer - inner
+	if ringWidth <= 0 {
+		return
+	}
+	vector.StrokeCircle(screen, float32(cx), float32(cy), float32(inner+ringWidth/2), float32(ringWidth), t.props.Color, true)
+}
+
+func scaleAlpha(c color.Color, alpha float64) color.Color {
+	r, g, b, a := c.RGBA()
+	return color.RGBA64{
+		R: uint16(float64(r) * alpha),
+		G: uint16(float64(g) * alpha),
+		B: uint16(float64(b) * alpha),
+		A: uint16(float64(a) * alpha),
+	}
+}
+
+type levelSwitchPhase int
+
+const (
+	levelSwitchLeaving levelSwitchPhase = iota
+	levelSwitchLoading
+	levelSwitchEntering
+)
+
+type levelSwitch struct {
+	target    int
+	phase     levelSwitchPhase
+	elapsed   float64
+	loaded    chan error
+	loadedMap *loadedMap
+}
+
+func (w *World) transitionSeconds() float64 {
+	if w.Transition == nil {
+		return 0
+	}
+	return w.Transition.Duration().Seconds()
+}
+
+// beginLevelSwitch starts switching to the target level, covering the
+// outgoing level first when a Transition is set and a level is mounted.
+func (w *World) beginLevelSwitch(target int) {
+	w.levelSwitch = &levelSwitch{
+		target: target,
+		phase:  levelSwitchLeaving,
+	}
+
+	if !w.levelLoaded || w.transitionSeconds() == 0 {
+		w.startLevelLoad()
+	}
+}
+
+// startLevelLoad unmounts the outgoing level and runs the incoming level's
+// Preload, then reads its map file, in the background.
+func (w *World) startLevelLoad() {
+	w.unloadLevel()
+
+	ls := w.levelSwitch
+	ls.phase = levelSwitchLoading
+	ls.elapsed = 0
+	ls.loaded = make(chan error, 1)
+
+	w.CurrentLevel = ls.target
+	level := w.Levels[ls.target]
+
+	go func() {
+		if level.Preload != nil {
+			if err := level.Preload(w); err != nil {
+				ls.loaded <- err
+				return
+			}
+		}
+
+		loaded, err := w.loadLevelMap(level)
+		ls.loadedMap = loaded
+		ls.loaded <- err
+	}()
+}
+
+// updateLevelSwitch advances the switch in progress by one step. It reports
+// whether the incoming level was mounted during this step.
+func (w *World) updateLevelSwitch() (bool, error) {
+	ls := w.levelSwitch
+
+	switch ls.phase {
+	case levelSwitchLeaving:
+		ls.elapsed += w.FixedStep
+		if ls.elapsed >= w.transitionSeconds() {
+			w.startLevelLoad()
+			return false, nil
+		}
+
+	case levelSwitchLoading:
+		select {
+		case err := <-ls.loaded:
+			if err != nil {
+				w.levelSwitch = nil
+				return false, fmt.Errorf("failed to load level %d: %w", ls.target, err)
+			}
+
+			w.loadedMap = ls.loadedMap
+			w.mountLevel(ls.target, w.Rand.Int63())
+			ls.phase = levelSwitchEntering
+			ls.elapsed = 0
+			if w.transitionSeconds() == 0 {
+				w.levelSwitch = nil
+			}
+			return true, nil
+		default:
+		}
+
+	case levelSwitchEntering:
+		ls.elapsed += w.FixedStep
+		if ls.elapsed >= w.transitionSeconds() {
+			w.levelSwitch = nil
+		}
+	}
+
+	return false, nil
+}
+
+// IsSwitchingLevel reports whether a level switch, including its transition,
+// is still in progress.
+func (w *World) IsSwitchingLevel() bool {
+	return w.levelSwitch != nil
+}
+
+func (w *World) isLoadingLevel() bool {
+	return w.levelSwitch != nil && w.levelSwitch.phase == levelSwitchLoading
+}
+
+func (w *World) drawLevelSwitch(screen *ebiten.Image) {
+	ls := w.levelSwitch
+	if ls == nil {
+		return
+	}
+
+	duration := w.transitionSeconds()
+
+	if w.Transition != nil && duration > 0 {
+		switch ls.phase {
+		case levelSwitchLeaving:
+			w.Transition.Draw(screen, math.Min(ls.elapsed/duration, 1), false)
+		case levelSwitchLoading:
+			w.Transition.Draw(screen, 1, false)
+		case levelSwitchEntering:
+			w.Transition.Draw(screen, math.Max(1-ls.elapsed/duration, 0), true)
+		}
+	}
+
+	if ls.phase == levelSwitchLoading && w.LoadingScreen != nil {
+		w.LoadingScreen(screen)
+	}
+}
@@ -0,0 +1,383 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"time"
+)
+
+// Easing maps the linear progress of a tween, from 0 to 1, to the eased
+// progress. Most curves stay within 0 and 1; the Back and Elastic ones
+// overshoot.
+type Easing func(t float64) float64
+
+func Linear(t float64) float64 { return t }
+
+func EaseInQuad(t float64) float64    { return t * t }
+func EaseOutQuad(t float64) float64   { return 1 - (1-t)*(1-t) }
+func EaseInOutQuad(t float64) float64 { return easeInOut(EaseInQuad, t) }
+
+func EaseInCubic(t float64) float64    { return t * t * t }
+func EaseOutCubic(t float64) float64   { return 1 - math.Pow(1-t, 3) }
+func EaseInOutCubic(t float64) float64 { return easeInOut(EaseInCubic, t) }
+
+func EaseInSine(t float64) float64    { return 1 - math.Cos(t*math.Pi/2) }
+func EaseOutSine(t float64) float64   { return math.Sin(t * math.Pi / 2) }
+func EaseInOutSine(t float64) float64 { return -(math.Cos(math.Pi*t) - 1) / 2 }
+
+func EaseInExpo(t float64) float64 {
+	if t == 0 {
+		return 0
+	}
+	return math.Pow(2, 10*t-10)
+}
+
+func EaseOutExpo(t float64) float64 {
+	if t == 1 {
+		return 1
+	}
+	return 1 - math.Pow(2, -10*t)
+}
+
+func EaseInOutExpo(t float64) float64 { return easeInOut(EaseInExpo, t) }
+
+const easeBackOvershoot = 1.70158
+
+func EaseInBack(t float64) float64 {
+	return (easeBackOvershoot+1)*t*t*t - easeBackOvershoot*t*t
+}
+
+func EaseOutBack(t float64) float64     { return 1 - EaseInBack(1-t) }
+func EaseInOutBack(t float64) float64   { return easeInOut(EaseInBack, t) }
+func EaseInElastic(t float64) float64   { return 1 - EaseOutElastic(1-t) }
+func EaseInBounce(t float64) float64    { return 1 - EaseOutBounce(1-t) }
+func EaseInOutBounce(t float64) float64 { return easeInOut(EaseInBounce, t) }
+
+func EaseOutElastic(t float64) float64 {
+	if t == 0 || t == 1 {
+		return t
+	}
+	return math.Pow(2, -10*t)*math.Sin((t*10-0.75)*(2*math.Pi)/3) + 1
+}
+
+func EaseOutBounce(t float64) float64 {
+	const n, d = 7.5625, 2.75
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	default:
+		t -= 2.625 / d
+		return n*t*t + 0.984375
+	}
+}
+
+// easeInOut runs the ease-in curve over the first half and its mirror over
+// the second.
+func easeInOut(in Easing, t float64) float64 {
+	if t < 0.5 {
+		return in(t*2) / 2
+	}
+	return 1 - in((1-t)*2)/2
+}
+
+// TweenProps are the values a tween animates to, by property name: X, Y,
+// Scale, Opacity, RotationAngle (in radians, like the field) and Background
+// (a color.Color or a "#rrggbb" string). Any other numeric field or custom
+// property of the shape is animated through Shape.Set.
+type TweenProps map[string]interface{}
+
+// TweenHandle controls a running tween. Its setters return the handle so
+// they can be chained after Tween.
+type TweenHandle struct {
+	shape    *Shape
+	to       TweenProps
+	duration float64
+	easing   Easing
+
+	delay   float64
+	repeat  int
+	yoyo    bool
+	reverse bool
+	elapsed float64
+
+	from       map[string]float64
+	fromColor  color.NRGBA
+	toColor    color.NRGBA
+	hasColor   bool
+	started    bool
+	active     bool
+	onComplete func()
+	next       []*TweenHandle
+}
+
+// Tween animates the shape's properties from their current values to props
+// over duration, on its world's game clock, so tweens pause and scale with
+// the world's timers. A nil easing is Linear. Positions and rotations move
+// the body too, and are drawn interpolated like physics motion. Tweens of
+// shapes that are not registered yet start when they are.
+func Tween(shape *Shape, props TweenProps, duration time.Duration, easing Easing) *TweenHandle {
+	t := newTween(shape, props, duration, easing)
+	t.start()
+	return t
+}
+
+func newTween(shape *Shape, props TweenProps, duration time.Duration, easing Easing) *TweenHandle {
+	if easing == nil {
+		easing = Linear
+	}
+
+	return &TweenHandle{
+		shape:    shape,
+		to:       props,
+		duration: duration.Seconds(),
+		easing:   easing,
+		active:   true,
+	}
+}
+
+func (t *TweenHandle) start() {
+	if w := t.shape.world; w != nil {
+		w.tweens = append(w.tweens, t)
+	} else {
+		t.shape.pendingTweens = append(t.shape.pendingTweens, t)
+	}
+}
+
+// Delay waits d of game time before the tween starts.
+func (t *TweenHandle) Delay(d time.Duration) *TweenHandle {
+	t.delay = d.Seconds()
+	return t
+}
+
+// Repeat plays the tween count more times after the first, or forever if
+// count is negative.
+func (t *TweenHandle) Repeat(count int) *TweenHandle {
+	t.repeat = count
+	return t
+}
+
+// Yoyo makes every repeat play backwards from where the last one ended, so
+// Repeat(1).Yoyo() goes there and back.
+func (t *TweenHandle) Yoyo() *TweenHandle {
+	t.yoyo = true
+	return t
+}
+
+// OnComplete calls fn when the tween and its repeats have finished.
+func (t *TweenHandle) OnComplete(fn func()) *TweenHandle {
+	t.onComplete = fn
+	return t
+}
+
+// Then returns a tween of the same shape that starts when this one
+// finishes, from wherever this one left it.
+func (t *TweenHandle) Then(props TweenProps, duration time.Duration, easing Easing) *TweenHandle {
+	next := newTween(t.shape, props, duration, easing)
+	t.next = append(t.next, next)
+	return next
+}
+
+// Cancel stops the tween where it is. Tweens chained with Then never start.
+func (t *TweenHandle) Cancel() {
+	t.active = false
+}
+
+// Active reports whether the tween is still waiting or running.
+func (t *TweenHandle) Active() bool {
+	return t.active
+}
+
+func (t *TweenHandle) capture() {
+	s := t.shape
+	t.from = make(map[string]float64, len(t.to))
+
+	for key, value := range t.to {
+		if strings.EqualFold(key, "Background") {
+			target, ok := value.(color.Color)
+			if text, isText := value.(string); isText {
+				parsed, err := ParseHexColor(text)
+				target, ok = parsed, err == nil
+			}
+			if ok && target != nil {
+				t.hasColor = true
+				t.toColor = color.NRGBAModel.Convert(target).(color.NRGBA)
+				t.fromColor = color.NRGBAModel.Convert(s.Background).(color.NRGBA)
+			}
+			continue
+		}
+
+		if _, ok := toFloat(value); !ok {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(key, "X"):
+			t.from[key] = s.X
+		case strings.EqualFold(key, "Y"):
+			t.from[key] = s.Y
+		case strings.EqualFold(key, "Scale"):
+			t.from[key] = s.Scale
+		case strings.EqualFold(key, "Opacity"):
+			t.from[key] = s.Opacity
+		case strings.EqualFold(key, "RotationAngle"):
+			t.from[key] = s.RotationAngle
+		default:
+			if current, ok := toFloat(s.Get(key)); ok {
+				t.from[key] = current
+			}
+		}
+	}
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func (t *TweenHandle) apply(progress float64) {
+	s := t.shape
+	x, y, angle := s.X, s.Y, s.RotationAngle
+	moved, turned := false, false
+
+	for key, from := range t.from {
+		to, _ := toFloat(t.to[key])
+		value := lerp(from, to, progress)
+
+		switch {
+		case strings.EqualFold(key, "X"):
+			x, moved = value, true
+		case strings.EqualFold(key, "Y"):
+			y, moved = value, true
+		case strings.EqualFold(key, "Scale"):
+			s.SetScale(value)
+		case strings.EqualFold(key, "Opacity"):
+			s.Opacity = value
+		case strings.EqualFold(key, "RotationAngle"):
+			angle, turned = value, true
+		default:
+			s.Set(key, value)
+		}
+	}
+
+	// Shapes not registered yet have no body to keep in sync.
+	if moved {
+		if s.Body != nil {
+			s.SetPosition(x, y)
+		} else {
+			s.X, s.Y = x, y
+		}
+	}
+	if turned {
+		if s.Body != nil {
+			s.SetRotation(angle)
+		} else {
+			s.RotationAngle = angle
+		}
+	}
+
+	if t.hasColor {
+		channel := func(a, b uint8) uint8 {
+			return uint8(math.Round(math.Max(0, math.Min(255, lerp(float64(a), float64(b), progress)))))
+		}
+		s.SetBackground(color.NRGBA{
+			R: channel(t.fromColor.R, t.toColor.R),
+			G: channel(t.fromColor.G, t.toColor.G),
+			B: channel(t.fromColor.B, t.toColor.B),
+			A: channel(t.fromColor.A, t.toColor.A),
+		})
+	}
+}
+
+// update advances the tween by delta seconds of game time and reports
+// whether it has finished.
+func (t *TweenHandle) update(delta float64) bool {
+	if t.delay > 0 {
+		t.delay -= delta
+		if t.delay > 0 {
+			return false
+		}
+		delta = -t.delay
+		t.delay = 0
+	}
+
+	if !t.started {
+		t.capture()
+		t.started = true
+	}
+
+	t.elapsed += delta
+	progress := 1.0
+	if t.duration > 0 {
+		progress = math.Min(t.elapsed/t.duration, 1)
+	}
+
+	if t.reverse {
+		t.apply(t.easing(1 - progress))
+	} else {
+		t.apply(t.easing(progress))
+	}
+
+	if progress < 1 {
+		return false
+	}
+
+	if t.repeat == 0 {
+		return true
+	}
+	if t.repeat > 0 {
+		t.repeat--
+	}
+	t.elapsed = math.Max(t.elapsed-t.duration, 0)
+	if t.yoyo {
+		t.reverse = !t.reverse
+	}
+	return false
+}
+
+// updateTweens advances every tween by delta seconds of game time, then
+// completes the finished ones and starts what they chain to.
+func (w *World) updateTweens(delta float64) {
+	tweens := append([]*TweenHandle(nil), w.tweens...)
+
+	var finished []*TweenHandle
+	for _, t := range tweens {
+		if t.active && t.update(delta) {
+			t.active = false
+			finished = append(finished, t)
+		}
+	}
+
+	active := w.tweens[:0]
+	for _, t := range w.tweens {
+		if t.active {
+			active = append(active, t)
+		}
+	}
+	for i := len(active); i < len(w.tweens); i++ {
+		w.tweens[i] = nil
+	}
+	w.tweens = active
+
+	for _, t := range finished {
+		if t.onComplete != nil {
+			t.onComplete()
+		}
+		for _, next := range t.next {
+			next.start()
+		}
+	}
+}
+
+// clearTweens cancels every tween.
+func (w *World) clearTweens() {
+	for _, t := range w.tweens {
+		t.active = false
+	}
+	w.tweens = nil
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"image/color"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type ButtonProps struct {
+	ElementProps
+	Text    string
+	OnClick func()
+}
+
+// Button calls OnClick when clicked, or activated with Enter, Space or the
+// gamepad's bottom face button while focused. Keyboard and gamepad
+// activations emit life.EventClick too, with nil data.
+type Button struct {
+	Element
+	Text    string
+	OnClick func()
+}
+
+func NewButton(props *ButtonProps) *Button {
+	if props == nil {
+		props = &ButtonProps{}
+	}
+
+	button := &Button{
+		Element: newElement(props.ElementProps, true),
+		Text:    props.Text,
+		OnClick: props.OnClick,
+	}
+	button.themePadding = true
+
+	button.On(life.EventClick, func(data interface{}) {
+		if !button.IsDisabled() && button.OnClick != nil {
+			button.OnClick()
+		}
+	})
+	return button
+}
+
+func (b *Button) Measure() (float64, float64) {
+	return b.measureText(b.Text)
+}
+
+func (b *Button) HandleAction(ui *UI, action Action) bool {
+	if action != ActionActivate {
+		return false
+	}
+	b.Emit(life.EventClick, nil)
+	return true
+}
+
+// stateColor returns the theme's button color for the widget's state.
+func (e *Element) stateColor() color.Color {
+	theme := e.ResolvedTheme()
+	switch {
+	case e.IsDisabled():
+		return theme.ButtonDisabled
+	case e.Clicked && e.Hovered:
+		return theme.ButtonPressed
+	case e.Hovered || e.focused:
+		return theme.ButtonHover
+	default:
+		return theme.Button
+	}
+}
+
+func (b *Button) Draw(screen *ebiten.Image) {
+	theme := b.ResolvedTheme()
+	background := b.Background
+	if background == nil {
+		background = b.stateColor()
+	}
+	fillRect(screen, b.X, b.Y, b.Width, b.Height, b.fade(background))
+	strokeRect(screen, b.X, b.Y, b.Width, b.Height, theme.BorderWidth, b.fade(theme.Border))
+
+	x, y, width, height := b.content()
+	props := b.textProps(b.Text)
+	props.X, props.Y = x+width/2, y+height/2
+	props.Align = life.AlignCenter
+	life.DrawText(screen, props)
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type CheckboxProps struct {
+	ElementProps
+	Text     string
+	Checked  bool
+	OnChange func(checked bool)
+}
+
+// Checkbox toggles when clicked or activated.
+type Checkbox struct {
+	Element
+	Text     string
+	Checked  bool
+	OnChange func(checked bool)
+}
+
+func NewCheckbox(props *CheckboxProps) *Checkbox {
+	if props == nil {
+		props = &CheckboxProps{}
+	}
+
+	checkbox := &Checkbox{
+		Element:  newElement(props.ElementProps, true),
+		Text:     props.Text,
+		Checked:  props.Checked,
+		OnChange: props.OnChange,
+	}
+	checkbox.themePadding = true
+
+	checkbox.On(life.EventClick, func(data interface{}) {
+		if !checkbox.IsDisabled() {
+			checkbox.SetChecked(!checkbox.Checked)
+		}
+	})
+	return checkbox
+}
+
+func (c *Checkbox) SetChecked(checked bool) {
+	if c.Checked == checked {
+		return
+	}
+	c.Checked = checked
+	if c.OnChange != nil {
+		c.OnChange(checked)
+	}
+}
+
+// boxSize is the side of the box, as tall as the text.
+func (c *Checkbox) boxSize() float64 {
+	_, height := c.measureText("")
+	return height
+}
+
+func (c *Checkbox) Measure() (float64, float64) {
+	box := c.boxSize()
+	if c.Text == "" {
+		return box, box
+	}
+
+	width, height := c.measureText(c.Text)
+	return box + c.ResolvedTheme().Gap + width, max(box, height)
+}
+
+func (c *Checkbox) HandleAction(ui *UI, action Action) bool {
+	if action != ActionActivate {
+		return false
+	}
+	c.Emit(life.EventClick, nil)
+	return true
+}
+
+func (c *Checkbox) Draw(screen *ebiten.Image) {
+	theme := c.ResolvedTheme()
+	x, y, _, height := c.content()
+	box := c.boxSize()
+	boxY := y + (height-box)/2
+
+	fillRect(screen, x, boxY, box, box, c.fade(theme.Track))
+	border := theme.Border
+	if (c.Hovered || c.focused) && !c.IsDisabled() {
+		border = theme.Accent
+	}
+	strokeRect(screen, x, boxY, box, box, max(theme.BorderWidth, 1), c.fade(border))
+
+	if c.Checked {
+		inset := box / 4
+		mark := theme.Accent
+		if c.IsDisabled() {
+			mark = theme.TextDisabled
+		}
+		fillRect(screen, x+inset, boxY+inset, box-inset*2, box-inset*2, c.fade(mark))
+	}
+
+	if c.Text != "" {
+		props := c.textProps(c.Text)
+		props.X, props.Y = x+box+theme.Gap, y+height/2
+		life.DrawText(screen, props)
+	}
+}
@@ -0,0 +1,263 @@
+package ui
+
+import (
+	"image/color"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Insets are the space kept inside the edges of a widget, in pixels.
+type Insets struct {
+	Top, Right, Bottom, Left float64
+}
+
+// Pad returns the same insets on every side.
+func Pad(all float64) Insets {
+	return Insets{Top: all, Right: all, Bottom: all, Left: all}
+}
+
+// PadXY returns insets of x on the left and right and y on the top and
+// bottom.
+func PadXY(x, y float64) Insets {
+	return Insets{Top: y, Right: x, Bottom: y, Left: x}
+}
+
+func (i Insets) isZero() bool {
+	return i == Insets{}
+}
+
+// ElementProps are the settings every widget shares.
+type ElementProps struct {
+	Name string
+
+	// Width and Height fix the widget's size. Left at zero the widget is as
+	// big as its content, or stretches across rows and columns.
+	Width, Height float64
+
+	// Anchor, X and Y place the widget inside a panel with LayoutAnchor or
+	// LayoutStack, the same way they place a life.TextProps on the screen.
+	Anchor life.Anchor
+	X, Y   float64
+
+	Padding Insets
+
+	// Flex shares the free space along a row or column among the widgets
+	// that have it, in proportion.
+	Flex float64
+
+	Theme    *Theme
+	Disabled bool
+	Hidden   bool
+}
+
+// Widget is anything the UI lays out and draws. Widgets that hold others
+// implement Container, those that change over time Updater and those that
+// react to the keyboard or gamepad while focused ActionHandler.
+type Widget interface {
+	Base() *Element
+
+	// Measure returns the size of the widget's content, without padding,
+	// for when its Width or Height is not fixed.
+	Measure() (width, height float64)
+
+	// Draw draws the widget within its bounds. Containers draw only
+	// themselves; the UI draws their children after them.
+	Draw(screen *ebiten.Image)
+}
+
+// Container is a widget laying out other widgets within its bounds.
+type Container interface {
+	Widget
+	Children() []Widget
+
+	// Arrange sets the bounds of the children once the container's own are
+	// set.
+	Arrange()
+}
+
+type Updater interface {
+	Update(ui *UI)
+}
+
+// ActionHandler handles the actions of the focused widget. Actions it
+// does not handle move the focus or go to UI.OnCancel.
+type ActionHandler interface {
+	HandleAction(ui *UI, action Action) bool
+}
+
+// Element is the part of a widget the UI deals with. The embedded shape
+// carries the widget's events, such as life.EventClick and life.EventHover,
+// its Hovered and Clicked state, its Background and Opacity, and its bounds
+// from the last layout, in screen pixels, as X, Y, Width and Height.
+type Element struct {
+	*life.Shape
+
+	Size    life.Vector2
+	Anchor  life.Anchor
+	Offset  life.Vector2
+	Padding Insets
+	Flex    float64
+
+	Theme    *Theme
+	Disabled bool
+	Hidden   bool
+
+	focusable bool
+	focused   bool
+
+	// themePadding makes the widget use the theme's padding while its own
+	// is zero.
+	themePadding bool
+	parent       *Element
+	theme        *Theme
+	world        *life.World
+}
+
+func newElement(props ElementProps, focusable bool) Element {
+	shape := life.NewShape(&life.ShapeProps{
+		Name:   props.Name,
+		Tag:    "ui",
+		Width:  props.Width,
+		Height: props.Height,
+	})
+	shape.Background = nil
+
+	return Element{
+		Shape:     shape,
+		Size:      life.Vector2{X: props.Width, Y: props.Height},
+		Anchor:    props.Anchor,
+		Offset:    life.Vector2{X: props.X, Y: props.Y},
+		Padding:   props.Padding,
+		Flex:      props.Flex,
+		Theme:     props.Theme,
+		Disabled:  props.Disabled,
+		Hidden:    props.Hidden,
+		focusable: focusable,
+	}
+}
+
+func (e *Element) Base() *Element {
+	return e
+}
+
+// Focused reports whether the widget has the UI's focus.
+func (e *Element) Focused() bool {
+	return e.focused
+}
+
+// IsDisabled reports whether the widget or one of its parents is disabled.
+func (e *Element) IsDisabled() bool {
+	for el := e; el != nil; el = el.parent {
+		if el.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Element) canFocus() bool {
+	return e.focusable && !e.IsDisabled()
+}
+
+func (e *Element) Contains(x, y float64) bool {
+	return x >= e.X && x < e.X+e.Width && y >= e.Y && y < e.Y+e.Height
+}
+
+// ResolvedTheme returns the widget's theme, or the closest one above it.
+func (e *Element) ResolvedTheme() *Theme {
+	if e.theme == nil {
+		return DefaultTheme()
+	}
+	return e.theme
+}
+
+// padding returns the widget's padding, or the theme's for widgets that
+// use it when none is set.
+func (e *Element) padding() Insets {
+	if e.themePadding && e.Padding.isZero() {
+		return e.ResolvedTheme().Padding
+	}
+	return e.Padding
+}
+
+// content returns the bounds inside the padding.
+func (e *Element) content() (x, y, width, height float64) {
+	p := e.padding()
+	return e.X + p.Left, e.Y + p.Top,
+		max(e.Width-p.Left-p.Right, 0), max(e.Height-p.Top-p.Bottom, 0)
+}
+
+func (e *Element) opacity() float64 {
+	opacity := 1.0
+	for el := e; el != nil; el = el.parent {
+		opacity *= el.Opacity
+	}
+	return opacity
+}
+
+// fade applies the opacity of the widget and its parents to c.
+func (e *Element) fade(c color.Color) color.Color {
+	if c == nil {
+		return nil
+	}
+
+	opacity := e.opacity()
+	if opacity >= 1 {
+		return c
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = uint8(float64(n.A) * max(opacity, 0))
+	return n
+}
+
+// textProps returns the props for drawing text in the widget's theme, in
+// the text color or the disabled one.
+func (e *Element) textProps(text string) *life.TextProps {
+	theme := e.ResolvedTheme()
+	c := theme.Text
+	if e.IsDisabled() {
+		c = theme.TextDisabled
+	}
+
+	props := &life.TextProps{
+		Text:          text,
+		Type:          theme.Font,
+		Size:          theme.FontSize,
+		Color:         e.fade(c),
+		VerticalAlign: life.AlignMiddle,
+	}
+	if e.world != nil {
+		props.Time = e.world.GameTime()
+	}
+	return props
+}
+
+func (e *Element) measureText(text string) (float64, float64) {
+	if text == "" {
+		_, height := life.MeasureText(e.textProps(" "))
+		return 0, height
+	}
+	return life.MeasureText(e.textProps(text))
+}
+
+// outerSize returns the size the widget takes in a layout, padding
+// included.
+func outerSize(w Widget) (float64, float64) {
+	e := w.Base()
+	width, height := e.Size.X, e.Size.Y
+	if width > 0 && height > 0 {
+		return width, height
+	}
+
+	contentWidth, contentHeight := w.Measure()
+	p := e.padding()
+	if width <= 0 {
+		width = contentWidth + p.Left + p.Right
+	}
+	if height <= 0 {
+		height = contentHeight + p.Top + p.Bottom
+	}
+	return width, height
+}
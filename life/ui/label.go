@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"image/color"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type LabelProps struct {
+	ElementProps
+	Text string
+
+	// Color and FontSize replace the theme's text color and font size.
+	Color    color.Color
+	FontSize float64
+	Align    life.TextAlign
+	Markup   bool
+}
+
+// Label draws text, wrapped to its Width when that is fixed.
+type Label struct {
+	Element
+	Text     string
+	Color    color.Color
+	FontSize float64
+	Align    life.TextAlign
+	Markup   bool
+}
+
+func NewLabel(props *LabelProps) *Label {
+	if props == nil {
+		props = &LabelProps{}
+	}
+
+	return &Label{
+		Element:  newElement(props.ElementProps, false),
+		Text:     props.Text,
+		Color:    props.Color,
+		FontSize: props.FontSize,
+		Align:    props.Align,
+		Markup:   props.Markup,
+	}
+}
+
+func (l *Label) props() *life.TextProps {
+	props := l.textProps(l.Text)
+	if l.Color != nil && !l.IsDisabled() {
+		props.Color = l.fade(l.Color)
+	}
+	if l.FontSize > 0 {
+		props.Size = l.FontSize
+	}
+	props.Markup = l.Markup
+	props.Align = l.Align
+
+	if l.Size.X > 0 {
+		p := l.padding()
+		props.MaxWidth = l.Size.X - p.Left - p.Right
+	}
+	return props
+}
+
+func (l *Label) Measure() (float64, float64) {
+	if l.Text == "" {
+		return l.measureText("")
+	}
+	return life.MeasureText(l.props())
+}
+
+func (l *Label) Draw(screen *ebiten.Image) {
+	x, y, width, height := l.content()
+	props := l.props()
+	props.Y = y + height/2
+
+	switch l.Align {
+	case life.AlignCenter:
+		props.X = x + width/2
+	case life.AlignRight:
+		props.X = x + width
+	default:
+		props.X = x
+	}
+	life.DrawText(screen, props)
+}
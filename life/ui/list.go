@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"math"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type ListProps struct {
+	ElementProps
+	Items    []string
+	Selected int
+
+	// Rows is how many items are shown when the list's Height is not
+	// fixed, all of them by default. RowHeight replaces the theme's.
+	Rows      int
+	RowHeight float64
+	OnSelect  func(index int, item string)
+}
+
+// List shows items one per row and selects one, by clicking it or with up
+// and down while focused. It scrolls to keep the selection in view.
+type List struct {
+	Element
+	Items     []string
+	Selected  int
+	Rows      int
+	RowHeight float64
+	OnSelect  func(index int, item string)
+
+	scroll int
+	hoverY float64
+}
+
+func NewList(props *ListProps) *List {
+	if props == nil {
+		props = &ListProps{}
+	}
+
+	list := &List{
+		Element:   newElement(props.ElementProps, true),
+		Items:     props.Items,
+		Selected:  props.Selected,
+		Rows:      props.Rows,
+		RowHeight: props.RowHeight,
+		OnSelect:  props.OnSelect,
+	}
+	if list.Padding.isZero() {
+		list.Padding = Pad(2)
+	}
+
+	trackHover := func(data interface{}) {
+		if mouse, ok := data.(life.Vector2); ok {
+			list.hoverY = mouse.Y
+		}
+	}
+	list.On(life.EventHover, trackHover)
+	list.On(life.EventMouseMove, trackHover)
+
+	list.On(life.EventClick, func(data interface{}) {
+		mouse, ok := data.(life.Vector2)
+		if !ok || list.IsDisabled() {
+			return
+		}
+		if index := list.rowAt(mouse.Y); index >= 0 {
+			list.Select(index)
+		}
+	})
+	return list
+}
+
+func (l *List) rowHeight() float64 {
+	if l.RowHeight > 0 {
+		return l.RowHeight
+	}
+	return l.ResolvedTheme().RowHeight
+}
+
+// visibleRows returns how many rows fit in the list.
+func (l *List) visibleRows() int {
+	_, _, _, height := l.content()
+	return max(int(height/l.rowHeight()), 1)
+}
+
+// rowAt returns the index of the item at screen height y, or -1.
+func (l *List) rowAt(y float64) int {
+	_, top, _, _ := l.content()
+	if y < top {
+		return -1
+	}
+	index := l.scroll + int((y-top)/l.rowHeight())
+	if index >= len(l.Items) || index-l.scroll >= l.visibleRows() {
+		return -1
+	}
+	return index
+}
+
+// Select selects the item at index, scrolling to it, and calls OnSelect if
+// the selection changed.
+func (l *List) Select(index int) {
+	if index < 0 || index >= len(l.Items) {
+		return
+	}
+
+	changed := index != l.Selected
+	l.Selected = index
+	l.ScrollTo(index)
+	if changed && l.OnSelect != nil {
+		l.OnSelect(index, l.Items[index])
+	}
+}
+
+// ScrollTo scrolls the least needed to show the item at index.
+func (l *List) ScrollTo(index int) {
+	rows := l.visibleRows()
+	if index < l.scroll {
+		l.scroll = index
+	} else if index >= l.scroll+rows {
+		l.scroll = index - rows + 1
+	}
+	l.scroll = max(0, min(l.scroll, len(l.Items)-rows))
+}
+
+func (l *List) Measure() (float64, float64) {
+	width := 0.0
+	for _, item := range l.Items {
+		w, _ := l.measureText(item)
+		width = math.Max(width, w)
+	}
+
+	rows := len(l.Items)
+	if l.Rows > 0 {
+		rows = l.Rows
+	}
+	return width + l.rowHeight()/2, float64(max(rows, 1)) * l.rowHeight()
+}
+
+func (l *List) HandleAction(ui *UI, action Action) bool {
+	switch action {
+	case ActionUp:
+		if l.Selected <= 0 {
+			return false
+		}
+		l.Select(l.Selected - 1)
+	case ActionDown:
+		if l.Selected >= len(l.Items)-1 {
+			return false
+		}
+		l.Select(l.Selected + 1)
+	default:
+		return false
+	}
+	return true
+}
+
+func (l *List) Draw(screen *ebiten.Image) {
+	theme := l.ResolvedTheme()
+	fillRect(screen, l.X, l.Y, l.Width, l.Height, l.fade(theme.Track))
+
+	border := theme.Border
+	if l.focused {
+		border = theme.Accent
+	}
+	strokeRect(screen, l.X, l.Y, l.Width, l.Height, theme.BorderWidth, l.fade(border))
+
+	x, y, width, _ := l.content()
+	rowHeight := l.rowHeight()
+	hovered := -1
+	if l.Hovered {
+		hovered = l.rowAt(l.hoverY)
+	}
+
+	for row := 0; row < l.visibleRows(); row++ {
+		index := l.scroll + row
+		if index >= len(l.Items) {
+			break
+		}
+		rowY := y + float64(row)*rowHeight
+
+		switch index {
+		case l.Selected:
+			fillRect(screen, x, rowY, width, rowHeight, l.fade(theme.ButtonHover))
+			fillRect(screen, x, rowY, 3, rowHeight, l.fade(theme.Accent))
+		case hovered:
+			fillRect(screen, x, rowY, width, rowHeight, l.fade(theme.Button))
+		}
+
+		props := l.textProps(l.Items[index])
+		props.X, props.Y = x+rowHeight/4, rowY+rowHeight/2
+		life.DrawText(screen, props)
+	}
+}
@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"image/color"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Layout is how a panel places its children.
+type Layout int
+
+const (
+	// LayoutAnchor places every child at its own size by its Anchor, X and
+	// Y.
+	LayoutAnchor Layout = iota
+	// LayoutRow and LayoutColumn line the children up, Gap apart, sharing
+	// free space by Flex.
+	LayoutRow
+	LayoutColumn
+	// LayoutStack lays the children over each other, filling the panel
+	// unless their size is fixed.
+	LayoutStack
+)
+
+// Align places children across a row or column, or along it.
+type Align int
+
+const (
+	AlignStretch Align = iota
+	AlignStart
+	AlignCenter
+	AlignEnd
+)
+
+type PanelProps struct {
+	ElementProps
+	Layout Layout
+	Gap    float64
+
+	// Align places the children across a row or column. Justify places
+	// them along it when none of them has Flex.
+	Align   Align
+	Justify Align
+
+	// Framed draws the theme's panel background and border behind the
+	// children. Background, when set, replaces the background color.
+	Framed     bool
+	Background color.Color
+
+	Children []Widget
+}
+
+// Panel holds other widgets, and is the only widget that does.
+type Panel struct {
+	Element
+	Layout  Layout
+	Gap     float64
+	Align   Align
+	Justify Align
+	Framed  bool
+
+	children []Widget
+}
+
+func NewPanel(props *PanelProps) *Panel {
+	if props == nil {
+		props = &PanelProps{}
+	}
+
+	panel := &Panel{
+		Element: newElement(props.ElementProps, false),
+		Layout:  props.Layout,
+		Gap:     props.Gap,
+		Align:   props.Align,
+		Justify: props.Justify,
+		Framed:  props.Framed,
+	}
+	panel.Background = props.Background
+	panel.Add(props.Children...)
+	return panel
+}
+
+// Row returns a panel laying children out left to right, gap apart.
+func Row(gap float64, children ...Widget) *Panel {
+	return NewPanel(&PanelProps{Layout: LayoutRow, Gap: gap, Children: children})
+}
+
+// Column returns a panel laying children out top to bottom, gap apart.
+func Column(gap float64, children ...Widget) *Panel {
+	return NewPanel(&PanelProps{Layout: LayoutColumn, Gap: gap, Children: children})
+}
+
+func (p *Panel) Add(children ...Widget) *Panel {
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		child.Base().parent = &p.Element
+		p.children = append(p.children, child)
+	}
+	return p
+}
+
+func (p *Panel) Remove(child Widget) {
+	for i, c := range p.children {
+		if c == child {
+			p.children = append(p.children[:i], p.children[i+1:]...)
+			child.Base().parent = nil
+			return
+		}
+	}
+}
+
+func (p *Panel) Clear() {
+	for _, child := range p.children {
+		child.Base().parent = nil
+	}
+	p.children = nil
+}
+
+func (p *Panel) Children() []Widget {
+	return p.children
+}
+
+func (p *Panel) visibleChildren() []Widget {
+	var visible []Widget
+	for _, child := range p.children {
+		if !child.Base().Hidden {
+			visible = append(visible, child)
+		}
+	}
+	return visible
+}
+
+// opaque reports whether the panel draws a background, and so catches the
+// pointer instead of letting it through to what is under it.
+func (p *Panel) opaque() bool {
+	return p.Framed || p.Background != nil
+}
+
+func (p *Panel) Measure() (float64, float64) {
+	children := p.visibleChildren()
+	gaps := p.Gap * float64(max(len(children)-1, 0))
+
+	var width, height float64
+	for _, child := range children {
+		w, h := outerSize(child)
+		offset := child.Base().Offset
+
+		switch p.Layout {
+		case LayoutRow:
+			width += w
+			height = max(height, h)
+		case LayoutColumn:
+			width = max(width, w)
+			height += h
+		default:
+			width = max(width, w+offset.X)
+			height = max(height, h+offset.Y)
+		}
+	}
+
+	switch p.Layout {
+	case LayoutRow:
+		width += gaps
+	case LayoutColumn:
+		height += gaps
+	}
+	return width, height
+}
+
+func place(child Widget, x, y, width, height float64) {
+	e := child.Base()
+	e.X, e.Y, e.Width, e.Height = x, y, width, height
+	if c, ok := child.(Container); ok {
+		c.Arrange()
+	}
+}
+
+// anchored returns where a child of the given size goes in the box by its
+// anchor and offset.
+func anchored(e *Element, boxX, boxY, boxWidth, boxHeight, width, height float64) (float64, float64) {
+	x := boxX + e.Offset.X
+	switch e.Anchor {
+	case life.AnchorTop, life.AnchorCenter, life.AnchorBottom:
+		x = boxX + (boxWidth-width)/2 + e.Offset.X
+	case life.AnchorTopRight, life.AnchorRight, life.AnchorBottomRight:
+		x = boxX + boxWidth - width - e.Offset.X
+	}
+
+	y := boxY + e.Offset.Y
+	switch e.Anchor {
+	case life.AnchorLeft, life.AnchorCenter, life.AnchorRight:
+		y = boxY + (boxHeight-height)/2 + e.Offset.Y
+	case life.AnchorBottomLeft, life.AnchorBottom, life.AnchorBottomRight:
+		y = boxY + boxHeight - height - e.Offset.Y
+	}
+	return x, y
+}
+
+func (p *Panel) Arrange() {
+	x, y, width, height := p.content()
+	children := p.visibleChildren()
+
+	switch p.Layout {
+	case LayoutRow, LayoutColumn:
+		p.arrangeLine(children, x, y, width, height)
+
+	case LayoutStack:
+		for _, child := range children {
+			e := child.Base()
+			w, h := width, height
+			if e.Size.X > 0 {
+				w = e.Size.X
+			}
+			if e.Size.Y > 0 {
+				h = e.Size.Y
+			}
+			cx, cy := anchored(e, x, y, width, height, w, h)
+			place(child, cx, cy, w, h)
+		}
+
+	default:
+		for _, child := range children {
+			w, h := outerSize(child)
+			cx, cy := anchored(child.Base(), x, y, width, height, w, h)
+			place(child, cx, cy, w, h)
+		}
+	}
+}
+
+// arrangeLine lays the children out in a row or column. Flexible children
+// start from nothing and share the free space; the others keep their size.
+func (p *Panel) arrangeLine(children []Widget, x, y, width, height float64) {
+	row := p.Layout == LayoutRow
+
+	mainSpace, crossSpace := height, width
+	if row {
+		mainSpace, crossSpace = width, height
+	}
+
+	mains := make([]float64, len(children))
+	crosses := make([]float64, len(children))
+	used := p.Gap * float64(max(len(children)-1, 0))
+	flex := 0.0
+
+	for i, child := range children {
+		e := child.Base()
+		w, h := outerSize(child)
+		main, cross, fixed := h, w, e.Size.Y > 0
+		crossFixed := e.Size.X > 0
+		if row {
+			main, cross, fixed = w, h, e.Size.X > 0
+			crossFixed = e.Size.Y > 0
+		}
+
+		if e.Flex > 0 && !fixed {
+			main = 0
+			flex += e.Flex
+		}
+		if p.Align == AlignStretch && !crossFixed {
+			cross = crossSpace
+		}
+
+		mains[i], crosses[i] = main, cross
+		used += main
+	}
+
+	free := mainSpace - used
+	offset := 0.0
+	if flex > 0 && free > 0 {
+		for i, child := range children {
+			if e := child.Base(); e.Flex > 0 && mains[i] == 0 {
+				mains[i] = free * e.Flex / flex
+			}
+		}
+	} else {
+		switch p.Justify {
+		case AlignCenter:
+			offset = free / 2
+		case AlignEnd:
+			offset = free
+		}
+	}
+
+	for i, child := range children {
+		crossOffset := 0.0
+		switch p.Align {
+		case AlignCenter:
+			crossOffset = (crossSpace - crosses[i]) / 2
+		case AlignEnd:
+			crossOffset = crossSpace - crosses[i]
+		}
+
+		if row {
+			place(child, x+offset, y+crossOffset, mains[i], crosses[i])
+		} else {
+			place(child, x+crossOffset, y+offset, crosses[i], mains[i])
+		}
+		offset += mains[i] + p.Gap
+	}
+}
+
+func (p *Panel) Draw(screen *ebiten.Image) {
+	if !p.opaque() {
+		return
+	}
+
+	theme := p.ResolvedTheme()
+	background := p.Background
+	if background == nil {
+		background = theme.Panel
+	}
+	fillRect(screen, p.X, p.Y, p.Width, p.Height, p.fade(background))
+
+	if p.Framed {
+		strokeRect(screen, p.X, p.Y, p.Width, p.Height, theme.BorderWidth, p.fade(theme.Border))
+	}
+}
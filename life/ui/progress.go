@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type ProgressBarProps struct {
+	ElementProps
+
+	// Value is how full the bar is, from 0 to 1.
+	Value float64
+
+	// Color replaces the theme's accent for the filled part. Text is drawn
+	// over the bar; ShowPercent draws the value as a percentage instead.
+	Color       color.Color
+	Text        string
+	ShowPercent bool
+}
+
+type ProgressBar struct {
+	Element
+	Value       float64
+	Color       color.Color
+	Text        string
+	ShowPercent bool
+}
+
+func NewProgressBar(props *ProgressBarProps) *ProgressBar {
+	if props == nil {
+		props = &ProgressBarProps{}
+	}
+
+	return &ProgressBar{
+		Element:     newElement(props.ElementProps, false),
+		Value:       props.Value,
+		Color:       props.Color,
+		Text:        props.Text,
+		ShowPercent: props.ShowPercent,
+	}
+}
+
+func (p *ProgressBar) label() string {
+	if p.ShowPercent {
+		return fmt.Sprintf("%d%%", int(math.Round(p.fraction()*100)))
+	}
+	return p.Text
+}
+
+func (p *ProgressBar) fraction() float64 {
+	return math.Max(0, math.Min(1, p.Value))
+}
+
+func (p *ProgressBar) Measure() (float64, float64) {
+	if p.label() == "" {
+		return 160, 12
+	}
+	_, height := p.measureText(p.label())
+	return 160, height + 4
+}
+
+func (p *ProgressBar) Draw(screen *ebiten.Image) {
+	theme := p.ResolvedTheme()
+	x, y, width, height := p.content()
+
+	fillRect(screen, x, y, width, height, p.fade(theme.Track))
+
+	fill := p.Color
+	if fill == nil {
+		fill = theme.Accent
+	}
+	fillRect(screen, x, y, width*p.fraction(), height, p.fade(fill))
+	strokeRect(screen, x, y, width, height, theme.BorderWidth, p.fade(theme.Border))
+
+	if label := p.label(); label != "" {
+		props := p.textProps(label)
+		props.X, props.Y = x+width/2, y+height/2
+		props.Align = life.AlignCenter
+		props.OutlineColor = p.fade(theme.Track)
+		props.OutlineWidth = 1
+		life.DrawText(screen, props)
+	}
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type SliderProps struct {
+	ElementProps
+	Value    float64
+	Min, Max float64
+
+	// Step snaps the value to multiples of it from Min, and is how far the
+	// arrow keys move the slider. Without it the keys move a twentieth of
+	// the range.
+	Step     float64
+	OnChange func(value float64)
+}
+
+// Slider picks a value between Min and Max by dragging, or with left and
+// right while focused.
+type Slider struct {
+	Element
+	Value    float64
+	Min, Max float64
+	Step     float64
+	OnChange func(value float64)
+}
+
+func NewSlider(props *SliderProps) *Slider {
+	if props == nil {
+		props = &SliderProps{}
+	}
+	if props.Max <= props.Min {
+		props.Max = props.Min + 1
+	}
+
+	slider := &Slider{
+		Element:  newElement(props.ElementProps, true),
+		Min:      props.Min,
+		Max:      props.Max,
+		Step:     props.Step,
+		OnChange: props.OnChange,
+	}
+	slider.Value = slider.clamp(props.Value)
+	return slider
+}
+
+func (s *Slider) clamp(value float64) float64 {
+	if s.Step > 0 {
+		value = s.Min + math.Round((value-s.Min)/s.Step)*s.Step
+	}
+	return math.Max(s.Min, math.Min(s.Max, value))
+}
+
+// SetValue moves the slider, snapped and clamped, calling OnChange if the
+// value changed.
+func (s *Slider) SetValue(value float64) {
+	value = s.clamp(value)
+	if value == s.Value {
+		return
+	}
+	s.Value = value
+	if s.OnChange != nil {
+		s.OnChange(value)
+	}
+}
+
+// Fraction returns how far along the range the value is, from 0 to 1.
+func (s *Slider) Fraction() float64 {
+	return (s.Value - s.Min) / (s.Max - s.Min)
+}
+
+func (s *Slider) Measure() (float64, float64) {
+	_, height := s.measureText("")
+	return 160, height
+}
+
+func (s *Slider) Update(ui *UI) {
+	if !s.Clicked || s.IsDisabled() {
+		return
+	}
+
+	x, _, width, _ := s.content()
+	if width <= 0 {
+		return
+	}
+	mouse := ui.Mouse()
+	s.SetValue(s.Min + (mouse.X-x)/width*(s.Max-s.Min))
+}
+
+func (s *Slider) HandleAction(ui *UI, action Action) bool {
+	step := s.Step
+	if step <= 0 {
+		step = (s.Max - s.Min) / 20
+	}
+
+	switch action {
+	case ActionLeft:
+		s.SetValue(s.Value - step)
+	case ActionRight:
+		s.SetValue(s.Value + step)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Slider) Draw(screen *ebiten.Image) {
+	theme := s.ResolvedTheme()
+	x, y, width, height := s.content()
+
+	track := math.Max(4, height/4)
+	trackY := y + (height-track)/2
+	fillRect(screen, x, trackY, width, track, s.fade(theme.Track))
+
+	fill := theme.Accent
+	if s.IsDisabled() {
+		fill = theme.TextDisabled
+	}
+	fillRect(screen, x, trackY, width*s.Fraction(), track, s.fade(fill))
+
+	knob := math.Max(6, height/2)
+	knobX := x + width*s.Fraction() - knob/2
+	fillRect(screen, knobX, y, knob, height, s.fade(s.stateColor()))
+	strokeRect(screen, knobX, y, knob, height, math.Max(theme.BorderWidth, 1), s.fade(theme.Border))
+}
@@ -0,0 +1,432 @@
+package ui
+
+import (
+	"image"
+	"math"
+	"unicode"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type TextInputProps struct {
+	ElementProps
+	Text string
+
+	// Placeholder is shown, dimmed, while the field is empty.
+	Placeholder string
+
+	// MaxLength caps the text at that many characters; longer input is cut
+	// short. Zero means no limit.
+	MaxLength int
+
+	// Validate is asked about every edit with the text it would leave, and
+	// the edit is dropped when it returns false.
+	Validate func(text string) bool
+
+	OnChange func(text string)
+
+	// OnSubmit is called when Enter is pressed while the field is focused.
+	OnSubmit func(text string)
+}
+
+// TextInput is a one-line field edited with the keyboard while focused.
+// The arrow keys, Home and End move the cursor, with Shift to select and
+// Ctrl to move by words; clicking places the cursor and dragging selects.
+// Ctrl+C, Ctrl+X and Ctrl+V copy, cut and paste through a clipboard kept
+// by the UI, not the system's.
+type TextInput struct {
+	Element
+	Placeholder string
+	MaxLength   int
+	Validate    func(text string) bool
+	OnChange    func(text string)
+	OnSubmit    func(text string)
+
+	text []rune
+
+	// cursor and anchor are rune indices; the text between them is
+	// selected.
+	cursor, anchor int
+	scroll         float64
+	blink          int
+	dragging       bool
+	wasFocused     bool
+	held           map[ebiten.Key]int
+}
+
+// Steps the cursor stays shown, then hidden, while blinking.
+const blinkSteps = 30
+
+var textInputKeys = []ebiten.Key{
+	ebiten.KeyArrowLeft, ebiten.KeyArrowRight, ebiten.KeyHome, ebiten.KeyEnd,
+	ebiten.KeyBackspace, ebiten.KeyDelete, ebiten.KeyEnter, ebiten.KeyNumpadEnter,
+	ebiten.KeyA, ebiten.KeyC, ebiten.KeyX, ebiten.KeyV,
+}
+
+func NewTextInput(props *TextInputProps) *TextInput {
+	if props == nil {
+		props = &TextInputProps{}
+	}
+
+	input := &TextInput{
+		Element:     newElement(props.ElementProps, true),
+		Placeholder: props.Placeholder,
+		MaxLength:   props.MaxLength,
+		Validate:    props.Validate,
+		OnChange:    props.OnChange,
+		OnSubmit:    props.OnSubmit,
+		held:        make(map[ebiten.Key]int),
+	}
+	if input.Padding.isZero() {
+		input.Padding = PadXY(6, 4)
+	}
+
+	input.text = input.limit([]rune(props.Text))
+	input.cursor = len(input.text)
+	input.anchor = input.cursor
+	return input
+}
+
+func (t *TextInput) Text() string {
+	return string(t.text)
+}
+
+// SetText replaces the text, cut to MaxLength, and moves the cursor to its
+// end. It calls OnChange if the text changed.
+func (t *TextInput) SetText(text string) {
+	runes := t.limit([]rune(text))
+	changed := string(runes) != string(t.text)
+	t.text = runes
+	t.cursor = len(runes)
+	t.anchor = t.cursor
+	if changed && t.OnChange != nil {
+		t.OnChange(string(runes))
+	}
+}
+
+// Selection returns the rune indices the selection starts and ends at,
+// equal when nothing is selected.
+func (t *TextInput) Selection() (start, end int) {
+	return min(t.cursor, t.anchor), max(t.cursor, t.anchor)
+}
+
+func (t *TextInput) SelectedText() string {
+	start, end := t.Selection()
+	return string(t.text[start:end])
+}
+
+func (t *TextInput) SelectAll() {
+	t.anchor = 0
+	t.cursor = len(t.text)
+}
+
+func (t *TextInput) limit(runes []rune) []rune {
+	if t.MaxLength > 0 && len(runes) > t.MaxLength {
+		return runes[:t.MaxLength]
+	}
+	return runes
+}
+
+// moveTo moves the cursor, dragging the selection along when selecting.
+func (t *TextInput) moveTo(index int, selecting bool) {
+	t.cursor = max(0, min(index, len(t.text)))
+	if !selecting {
+		t.anchor = t.cursor
+	}
+	t.blink = 0
+}
+
+// edit replaces the runes between start and end with insert, cut to what
+// MaxLength leaves room for, if Validate allows it.
+func (t *TextInput) edit(start, end int, insert []rune) {
+	if t.MaxLength > 0 {
+		room := max(t.MaxLength-(len(t.text)-(end-start)), 0)
+		if len(insert) > room {
+			insert = insert[:room]
+		}
+	}
+	if start == end && len(insert) == 0 {
+		return
+	}
+
+	text := make([]rune, 0, len(t.text)-(end-start)+len(insert))
+	text = append(text, t.text[:start]...)
+	text = append(text, insert...)
+	text = append(text, t.text[end:]...)
+	if t.Validate != nil && !t.Validate(string(text)) {
+		return
+	}
+
+	t.text = text
+	t.moveTo(start+len(insert), false)
+	if t.OnChange != nil {
+		t.OnChange(string(text))
+	}
+}
+
+// insert types runes over the selection.
+func (t *TextInput) insert(runes []rune) {
+	start, end := t.Selection()
+	t.edit(start, end, runes)
+}
+
+// remove deletes the selection, or else the text between the cursor and
+// index.
+func (t *TextInput) remove(index int) {
+	start, end := t.Selection()
+	if start == end {
+		start, end = min(t.cursor, index), max(t.cursor, index)
+	}
+	t.edit(max(start, 0), min(end, len(t.text)), nil)
+}
+
+// wordLeft returns the start of the word before index.
+func (t *TextInput) wordLeft(index int) int {
+	for index > 0 && unicode.IsSpace(t.text[index-1]) {
+		index--
+	}
+	for index > 0 && !unicode.IsSpace(t.text[index-1]) {
+		index--
+	}
+	return index
+}
+
+// wordRight returns the end of the word after index.
+func (t *TextInput) wordRight(index int) int {
+	for index < len(t.text) && unicode.IsSpace(t.text[index]) {
+		index++
+	}
+	for index < len(t.text) && !unicode.IsSpace(t.text[index]) {
+		index++
+	}
+	return index
+}
+
+// offset returns how far from the start of the text the rune at index is
+// drawn.
+func (t *TextInput) offset(index int) float64 {
+	if index <= 0 {
+		return 0
+	}
+	width, _ := life.MeasureText(t.textProps(string(t.text[:index])))
+	return width
+}
+
+// indexAt returns the rune index closest to screen x.
+func (t *TextInput) indexAt(x float64) int {
+	left, _, _, _ := t.content()
+	x += t.scroll - left
+
+	best, bestDistance := 0, math.Inf(1)
+	for i := 0; i <= len(t.text); i++ {
+		if distance := math.Abs(t.offset(i) - x); distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// pressed reports whether key went down this step, or has been held long
+// enough to repeat.
+func (t *TextInput) pressed(key ebiten.Key, repeats bool) bool {
+	steps := t.held[key]
+	if steps == 1 {
+		return true
+	}
+	return repeats && steps > repeatDelay && (steps-repeatDelay)%repeatInterval == 0
+}
+
+func (t *TextInput) updateHeld(ui *UI) {
+	for _, key := range textInputKeys {
+		if ui.world.IsKeyPressed(key) {
+			t.held[key]++
+		} else {
+			t.held[key] = 0
+		}
+	}
+}
+
+func (t *TextInput) Update(ui *UI) {
+	focused := t.focused && !t.IsDisabled()
+	if !focused {
+		t.wasFocused = false
+		t.dragging = false
+		return
+	}
+
+	if !t.wasFocused {
+		// Keys held as the field gets the focus, such as the arrow that
+		// moved it here, count once pressed again.
+		t.wasFocused = true
+		t.blink = 0
+		for _, key := range textInputKeys {
+			t.held[key] = 0
+			if ui.world.IsKeyPressed(key) {
+				t.held[key] = 1
+			}
+		}
+	}
+	t.updateHeld(ui)
+	t.blink++
+
+	world := ui.world
+	shift := world.IsKeyPressed(ebiten.KeyShift) ||
+		world.IsKeyPressed(ebiten.KeyShiftLeft) || world.IsKeyPressed(ebiten.KeyShiftRight)
+	ctrl := world.IsKeyPressed(ebiten.KeyControl) ||
+		world.IsKeyPressed(ebiten.KeyControlLeft) || world.IsKeyPressed(ebiten.KeyControlRight) ||
+		world.IsKeyPressed(ebiten.KeyMeta)
+
+	if t.Clicked {
+		index := t.indexAt(ui.Mouse().X)
+		if !t.dragging {
+			t.dragging = true
+			t.moveTo(index, shift)
+		} else if index != t.cursor {
+			t.moveTo(index, true)
+		}
+	} else {
+		t.dragging = false
+	}
+
+	start, end := t.Selection()
+	switch {
+	case t.pressed(ebiten.KeyArrowLeft, true):
+		switch {
+		case ctrl:
+			t.moveTo(t.wordLeft(t.cursor), shift)
+		case start != end && !shift:
+			t.moveTo(start, false)
+		default:
+			t.moveTo(t.cursor-1, shift)
+		}
+	case t.pressed(ebiten.KeyArrowRight, true):
+		switch {
+		case ctrl:
+			t.moveTo(t.wordRight(t.cursor), shift)
+		case start != end && !shift:
+			t.moveTo(end, false)
+		default:
+			t.moveTo(t.cursor+1, shift)
+		}
+	case t.pressed(ebiten.KeyHome, false):
+		t.moveTo(0, shift)
+	case t.pressed(ebiten.KeyEnd, false):
+		t.moveTo(len(t.text), shift)
+	case t.pressed(ebiten.KeyBackspace, true):
+		if ctrl {
+			t.remove(t.wordLeft(t.cursor))
+		} else {
+			t.remove(t.cursor - 1)
+		}
+	case t.pressed(ebiten.KeyDelete, true):
+		if ctrl {
+			t.remove(t.wordRight(t.cursor))
+		} else {
+			t.remove(t.cursor + 1)
+		}
+	case t.pressed(ebiten.KeyEnter, false) || t.pressed(ebiten.KeyNumpadEnter, false):
+		if t.OnSubmit != nil {
+			t.OnSubmit(string(t.text))
+		}
+	case ctrl && t.pressed(ebiten.KeyA, false):
+		t.SelectAll()
+	case ctrl && t.pressed(ebiten.KeyC, false):
+		if start != end {
+			ui.clipboard = append([]rune(nil), t.text[start:end]...)
+		}
+	case ctrl && t.pressed(ebiten.KeyX, false):
+		if start != end {
+			ui.clipboard = append([]rune(nil), t.text[start:end]...)
+			t.remove(t.cursor)
+		}
+	case ctrl && t.pressed(ebiten.KeyV, true):
+		t.insert(ui.clipboard)
+	}
+
+	if !ctrl {
+		var typed []rune
+		for _, r := range world.InputChars() {
+			if unicode.IsPrint(r) {
+				typed = append(typed, r)
+			}
+		}
+		if len(typed) > 0 {
+			t.insert(typed)
+		}
+	}
+
+	t.scrollToCursor()
+}
+
+// scrollToCursor scrolls the least needed to keep the cursor in view.
+func (t *TextInput) scrollToCursor() {
+	_, _, width, _ := t.content()
+	cursorX := t.offset(t.cursor)
+	if cursorX < t.scroll {
+		t.scroll = cursorX
+	} else if cursorX > t.scroll+width-1 {
+		t.scroll = cursorX - width + 1
+	}
+	t.scroll = math.Max(0, math.Min(t.scroll, t.offset(len(t.text))-width+1))
+}
+
+func (t *TextInput) Measure() (float64, float64) {
+	_, height := t.measureText("")
+	return 160, height
+}
+
+// HandleAction keeps left, right and activation, which edit the text, from
+// moving the focus.
+func (t *TextInput) HandleAction(ui *UI, action Action) bool {
+	switch action {
+	case ActionLeft, ActionRight, ActionActivate:
+		return true
+	}
+	return false
+}
+
+func (t *TextInput) Draw(screen *ebiten.Image) {
+	theme := t.ResolvedTheme()
+	fillRect(screen, t.X, t.Y, t.Width, t.Height, t.fade(theme.Track))
+
+	border := theme.Border
+	if (t.Hovered || t.focused) && !t.IsDisabled() {
+		border = theme.Accent
+	}
+	strokeRect(screen, t.X, t.Y, t.Width, t.Height, max(theme.BorderWidth, 1), t.fade(border))
+
+	x, y, width, height := t.content()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	clip := image.Rect(int(math.Floor(x)), int(math.Floor(y)), int(math.Ceil(x+width)), int(math.Ceil(y+height)))
+	area, ok := screen.SubImage(clip).(*ebiten.Image)
+	if !ok {
+		return
+	}
+
+	if len(t.text) == 0 {
+		if t.Placeholder != "" && !t.focused {
+			props := t.textProps(t.Placeholder)
+			props.X, props.Y = x, y+height/2
+			props.Color = t.fade(theme.TextDisabled)
+			life.DrawText(area, props)
+		}
+	} else {
+		if start, end := t.Selection(); start != end && t.focused {
+			left, right := t.offset(start), t.offset(end)
+			fillRect(area, x+left-t.scroll, y, right-left, height, t.fade(theme.ButtonHover))
+		}
+
+		props := t.textProps(string(t.text))
+		props.X, props.Y = x-t.scroll, y+height/2
+		life.DrawText(area, props)
+	}
+
+	if t.focused && !t.IsDisabled() && (t.blink/blinkSteps)%2 == 0 {
+		cursorX := math.Floor(x + t.offset(t.cursor) - t.scroll)
+		fillRect(area, cursorX, y, 1, height, t.fade(theme.Text))
+	}
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Theme holds the colors, font and spacing widgets are drawn with. Widgets
+// without a Theme use their parent's, up to the UI's.
+type Theme struct {
+	// Font is the name of a font registered with life.RegisterFont.
+	Font     string
+	FontSize float64
+
+	Text         color.Color
+	TextDisabled color.Color
+
+	Panel       color.Color
+	Border      color.Color
+	BorderWidth float64
+
+	Button         color.Color
+	ButtonHover    color.Color
+	ButtonPressed  color.Color
+	ButtonDisabled color.Color
+
+	// Accent fills sliders, progress bars, checked boxes and selected list
+	// rows; Track is the empty part behind them.
+	Accent color.Color
+	Track  color.Color
+
+	Focus      color.Color
+	FocusWidth float64
+
+	// Padding is used by buttons, checkboxes and lists without their own.
+	Padding   Insets
+	Gap       float64
+	RowHeight float64
+}
+
+func DefaultTheme() *Theme {
+	return &Theme{
+		FontSize:       14,
+		Text:           color.RGBA{R: 240, G: 240, B: 245, A: 255},
+		TextDisabled:   color.RGBA{R: 130, G: 130, B: 140, A: 255},
+		Panel:          color.RGBA{R: 24, G: 26, B: 36, A: 230},
+		Border:         color.RGBA{R: 70, G: 74, B: 96, A: 255},
+		BorderWidth:    1,
+		Button:         color.RGBA{R: 52, G: 56, B: 78, A: 255},
+		ButtonHover:    color.RGBA{R: 70, G: 76, B: 106, A: 255},
+		ButtonPressed:  color.RGBA{R: 38, G: 40, B: 58, A: 255},
+		ButtonDisabled: color.RGBA{R: 40, G: 42, B: 52, A: 255},
+		Accent:         color.RGBA{R: 255, G: 196, B: 60, A: 255},
+		Track:          color.RGBA{R: 16, G: 18, B: 26, A: 255},
+		Focus:          color.RGBA{R: 120, G: 190, B: 255, A: 255},
+		FocusWidth:     2,
+		Padding:        PadXY(12, 6),
+		Gap:            8,
+		RowHeight:      22,
+	}
+}
+
+func fillRect(screen *ebiten.Image, x, y, width, height float64, c color.Color) {
+	if c == nil || width <= 0 || height <= 0 {
+		return
+	}
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(width), float32(height), c, false)
+}
+
+func strokeRect(screen *ebiten.Image, x, y, width, height, strokeWidth float64, c color.Color) {
+	if c == nil || strokeWidth <= 0 || width <= 0 || height <= 0 {
+		return
+	}
+	vector.StrokeRect(screen, float32(x), float32(y), float32(width), float32(height), float32(strokeWidth), c, false)
+}
@@ -0,0 +1,476 @@
+// Package ui is a small widget toolkit drawn in screen space over the
+// world: buttons, labels, panels, sliders, checkboxes, progress bars, lists
+// and text inputs, laid out by panels and driven by the world's mouse and
+// keyboard and by gamepads.
+//
+// Widgets are not registered with the world and have no bodies, but each
+// carries a *life.Shape, so handlers are added with On(life.EventClick, ...)
+// and On(life.EventHover, ...) as for shapes. A UI is updated from a level's
+// or scene's Tick and drawn from its Render.
+package ui
+
+import (
+	"math"
+
+	"boughtnine/life"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Action is a navigation or activation input, from the keyboard or a
+// gamepad.
+type Action int
+
+const (
+	ActionUp Action = iota
+	ActionDown
+	ActionLeft
+	ActionRight
+	ActionNext
+	ActionPrevious
+	ActionActivate
+	ActionCancel
+)
+
+var actionKeys = map[Action][]ebiten.Key{
+	ActionUp:       {ebiten.KeyArrowUp},
+	ActionDown:     {ebiten.KeyArrowDown},
+	ActionLeft:     {ebiten.KeyArrowLeft},
+	ActionRight:    {ebiten.KeyArrowRight},
+	ActionActivate: {ebiten.KeyEnter, ebiten.KeyNumpadEnter, ebiten.KeySpace},
+	ActionCancel:   {ebiten.KeyEscape},
+}
+
+var actionButtons = map[Action]ebiten.StandardGamepadButton{
+	ActionUp:       ebiten.StandardGamepadButtonLeftTop,
+	ActionDown:     ebiten.StandardGamepadButtonLeftBottom,
+	ActionLeft:     ebiten.StandardGamepadButtonLeftLeft,
+	ActionRight:    ebiten.StandardGamepadButtonLeftRight,
+	ActionNext:     ebiten.StandardGamepadButtonFrontTopRight,
+	ActionPrevious: ebiten.StandardGamepadButtonFrontTopLeft,
+	ActionActivate: ebiten.StandardGamepadButtonRightBottom,
+	ActionCancel:   ebiten.StandardGamepadButtonRightRight,
+}
+
+// Held actions repeat after repeatDelay steps, every repeatInterval steps.
+const (
+	repeatDelay    = 24
+	repeatInterval = 5
+)
+
+// UI lays out, updates and draws a tree of widgets under Root, which fills
+// the world's screen.
+type UI struct {
+	Root  *Panel
+	Theme *Theme
+
+	// OnCancel is called on Escape, or the gamepad's right face button,
+	// when the focused widget does not handle it.
+	OnCancel func()
+
+	world    *life.World
+	focused  Widget
+	hovered  Widget
+	pressed  Widget
+	wasDown  bool
+	lastX    float64
+	lastY    float64
+	held     map[Action]int
+	keyboard bool
+	started  bool
+
+	// clipboard holds what text inputs copied and cut.
+	clipboard []rune
+}
+
+func New(world *life.World, theme *Theme) *UI {
+	if theme == nil {
+		theme = DefaultTheme()
+	}
+
+	return &UI{
+		Root:  NewPanel(nil),
+		Theme: theme,
+		world: world,
+		held:  make(map[Action]int),
+	}
+}
+
+// Add adds widgets to the root panel.
+func (ui *UI) Add(widgets ...Widget) *UI {
+	ui.Root.Add(widgets...)
+	return ui
+}
+
+// Mouse returns the cursor in screen pixels.
+func (ui *UI) Mouse() life.Vector2 {
+	return life.Vector2{X: ui.world.Mouse.X, Y: ui.world.Mouse.Y}
+}
+
+// Hovered returns the widget under the cursor, or nil. Games can use it to
+// ignore clicks meant for the UI.
+func (ui *UI) Hovered() Widget {
+	return ui.hovered
+}
+
+func (ui *UI) Focused() Widget {
+	return ui.focused
+}
+
+// Focus gives w the focus, or takes it away with nil. Widgets that cannot
+// be focused are ignored.
+func (ui *UI) Focus(w Widget) {
+	if w != nil && !w.Base().canFocus() {
+		return
+	}
+	if ui.focused != nil {
+		ui.focused.Base().focused = false
+	}
+	ui.focused = w
+	if w != nil {
+		w.Base().focused = true
+	}
+}
+
+// walk calls fn for every visible widget, parents before children.
+func walk(w Widget, fn func(w Widget)) {
+	if w.Base().Hidden {
+		return
+	}
+	fn(w)
+	if c, ok := w.(Container); ok {
+		for _, child := range c.Children() {
+			walk(child, fn)
+		}
+	}
+}
+
+// prepare links every widget to its parent and the world and resolves its
+// theme.
+func prepare(w Widget, parent *Element, theme *Theme, world *life.World) {
+	e := w.Base()
+	e.parent = parent
+	e.world = world
+	if e.Theme != nil {
+		theme = e.Theme
+	}
+	e.theme = theme
+
+	if c, ok := w.(Container); ok {
+		for _, child := range c.Children() {
+			prepare(child, e, theme, world)
+		}
+	}
+}
+
+func (ui *UI) layout() {
+	prepare(ui.Root, nil, ui.Theme, ui.world)
+	place(ui.Root, 0, 0, float64(ui.world.Width), float64(ui.world.Height))
+}
+
+// focusable returns the widgets that can take the focus, in tree order.
+func (ui *UI) focusable() []Widget {
+	var widgets []Widget
+	walk(ui.Root, func(w Widget) {
+		if w.Base().canFocus() {
+			widgets = append(widgets, w)
+		}
+	})
+	return widgets
+}
+
+// hitTest returns the topmost widget under the point. Panels without a
+// background let the pointer through.
+func (ui *UI) hitTest(w Widget, x, y float64) Widget {
+	e := w.Base()
+	if e.Hidden {
+		return nil
+	}
+
+	if c, ok := w.(Container); ok {
+		children := c.Children()
+		for i := len(children) - 1; i >= 0; i-- {
+			if hit := ui.hitTest(children[i], x, y); hit != nil {
+				return hit
+			}
+		}
+	}
+
+	if p, ok := w.(*Panel); ok && (p == ui.Root || !p.opaque()) {
+		return nil
+	}
+	if e.Contains(x, y) {
+		return w
+	}
+	return nil
+}
+
+// Update lays the widgets out and handles this step's input. Call it once
+// per tick. Buttons and keys already held on the first update, such as the
+// key that opened a menu, count once they are released and pressed again.
+func (ui *UI) Update() {
+	ui.layout()
+
+	if !ui.started {
+		ui.started = true
+		ui.wasDown = ui.world.Mouse.IsLeftClicked
+		for action := ActionUp; action <= ActionCancel; action++ {
+			if ui.actionPressed(action) {
+				ui.held[action] = 1
+			}
+		}
+	}
+
+	if ui.focused != nil {
+		if e := ui.focused.Base(); !e.canFocus() || !ui.contains(ui.focused) {
+			ui.Focus(nil)
+		}
+	}
+
+	ui.updatePointer()
+
+	walk(ui.Root, func(w Widget) {
+		if u, ok := w.(Updater); ok {
+			u.Update(ui)
+		}
+	})
+
+	ui.updateActions()
+}
+
+// contains reports whether w is a visible widget of the tree.
+func (ui *UI) contains(w Widget) bool {
+	found := false
+	walk(ui.Root, func(other Widget) {
+		found = found || other == w
+	})
+	return found
+}
+
+func (ui *UI) updatePointer() {
+	mouse := ui.Mouse()
+	down := ui.world.Mouse.IsLeftClicked
+	moved := mouse.X != ui.lastX || mouse.Y != ui.lastY
+	ui.lastX, ui.lastY = mouse.X, mouse.Y
+
+	target := ui.hitTest(ui.Root, mouse.X, mouse.Y)
+	if target != nil && target.Base().IsDisabled() {
+		target = nil
+	}
+
+	if target != ui.hovered {
+		if ui.hovered != nil {
+			e := ui.hovered.Base()
+			e.Hovered = false
+			e.Emit(life.EventMouseLeave, life.EventMouseEnterData{Shape: e.Shape})
+			e.Emit(life.EventUnHover, mouse)
+		}
+		if target != nil {
+			e := target.Base()
+			e.Hovered = true
+			e.Emit(life.EventMouseEnter, life.EventMouseEnterData{Shape: e.Shape})
+			e.Emit(life.EventHover, mouse)
+		}
+		ui.hovered = target
+	} else if target != nil && moved {
+		target.Base().Emit(life.EventMouseMove, mouse)
+	}
+
+	if down && !ui.wasDown && target != nil {
+		ui.pressed = target
+		e := target.Base()
+		e.Clicked = true
+		e.Emit(life.EventMouseDown, mouse)
+
+		ui.keyboard = false
+		if e.canFocus() {
+			ui.Focus(target)
+		}
+	}
+
+	if !down && ui.wasDown && ui.pressed != nil {
+		pressed := ui.pressed
+		pressed.Base().Clicked = false
+		ui.pressed = nil
+
+		if target != nil {
+			target.Base().Emit(life.EventMouseUp, mouse)
+		}
+		if target == pressed {
+			pressed.Base().Emit(life.EventClick, mouse)
+		}
+	}
+
+	ui.wasDown = down
+}
+
+func (ui *UI) actionPressed(action Action) bool {
+	for _, key := range actionKeys[action] {
+		if ui.world.IsKeyPressed(key) {
+			return true
+		}
+	}
+
+	shift := ui.world.IsKeyPressed(ebiten.KeyShift) ||
+		ui.world.IsKeyPressed(ebiten.KeyShiftLeft) || ui.world.IsKeyPressed(ebiten.KeyShiftRight)
+	switch action {
+	case ActionNext:
+		if ui.world.IsKeyPressed(ebiten.KeyTab) && !shift {
+			return true
+		}
+	case ActionPrevious:
+		if ui.world.IsKeyPressed(ebiten.KeyTab) && shift {
+			return true
+		}
+	}
+
+	// Gamepads are read from ebiten directly; the world's input only
+	// carries the keyboard and mouse.
+	if ui.world.Headless {
+		return false
+	}
+	button, ok := actionButtons[action]
+	if !ok {
+		return false
+	}
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) && ebiten.IsStandardGamepadButtonPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateActions fires the actions pressed this step, and those held long
+// enough to repeat, except activation and cancelling, which never repeat.
+func (ui *UI) updateActions() {
+	for action := ActionUp; action <= ActionCancel; action++ {
+		if !ui.actionPressed(action) {
+			ui.held[action] = 0
+			continue
+		}
+
+		ui.held[action]++
+		steps := ui.held[action]
+		repeats := action != ActionActivate && action != ActionCancel &&
+			steps > repeatDelay && (steps-repeatDelay)%repeatInterval == 0
+		if steps == 1 || repeats {
+			ui.fire(action)
+		}
+	}
+}
+
+func (ui *UI) fire(action Action) {
+	if action != ActionCancel {
+		ui.keyboard = true
+	}
+
+	if ui.focused != nil {
+		if handler, ok := ui.focused.(ActionHandler); ok && handler.HandleAction(ui, action) {
+			return
+		}
+	}
+
+	switch action {
+	case ActionNext, ActionPrevious:
+		ui.cycleFocus(action == ActionNext)
+	case ActionUp, ActionDown, ActionLeft, ActionRight:
+		if ui.focused == nil {
+			ui.cycleFocus(true)
+			return
+		}
+		if next := ui.neighbour(action); next != nil {
+			ui.Focus(next)
+		}
+	case ActionCancel:
+		if ui.OnCancel != nil {
+			ui.OnCancel()
+		}
+	}
+}
+
+// cycleFocus moves the focus to the next or previous focusable widget in
+// tree order, wrapping around.
+func (ui *UI) cycleFocus(forward bool) {
+	widgets := ui.focusable()
+	if len(widgets) == 0 {
+		return
+	}
+
+	current := -1
+	for i, w := range widgets {
+		if w == ui.focused {
+			current = i
+		}
+	}
+
+	switch {
+	case current < 0 && forward:
+		ui.Focus(widgets[0])
+	case current < 0:
+		ui.Focus(widgets[len(widgets)-1])
+	case forward:
+		ui.Focus(widgets[(current+1)%len(widgets)])
+	default:
+		ui.Focus(widgets[(current-1+len(widgets))%len(widgets)])
+	}
+}
+
+func center(e *Element) (float64, float64) {
+	return e.X + e.Width/2, e.Y + e.Height/2
+}
+
+// neighbour returns the closest focusable widget in the direction of the
+// action from the focused one, favouring widgets in line with it.
+func (ui *UI) neighbour(action Action) Widget {
+	fx, fy := center(ui.focused.Base())
+
+	var best Widget
+	bestScore := math.Inf(1)
+	for _, w := range ui.focusable() {
+		if w == ui.focused {
+			continue
+		}
+
+		x, y := center(w.Base())
+		dx, dy := x-fx, y-fy
+
+		var along, across float64
+		switch action {
+		case ActionUp:
+			along, across = -dy, dx
+		case ActionDown:
+			along, across = dy, dx
+		case ActionLeft:
+			along, across = -dx, dy
+		case ActionRight:
+			along, across = dx, dy
+		}
+		if along <= 0 {
+			continue
+		}
+
+		if score := along + math.Abs(across)*2; score < bestScore {
+			best, bestScore = w, score
+		}
+	}
+	return best
+}
+
+// Draw draws the widgets, then a ring around the focused one while the
+// keyboard or a gamepad is in use.
+func (ui *UI) Draw(screen *ebiten.Image) {
+	ui.layout()
+
+	walk(ui.Root, func(w Widget) {
+		w.Draw(screen)
+	})
+
+	if ui.focused != nil && ui.keyboard {
+		e := ui.focused.Base()
+		theme := e.ResolvedTheme()
+		inset := theme.FocusWidth / 2
+		strokeRect(screen, e.X-inset-1, e.Y-inset-1, e.Width+inset*2+2, e.Height+inset*2+2,
+			theme.FocusWidth, e.fade(theme.Focus))
+	}
+}
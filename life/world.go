@@ -2,15 +2,16 @@ package life
 
 import (
 	"embed"
+	"fmt"
 	"image/color"
 	"math"
+	"math/rand"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/ByteArena/box2d"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 type ContactListener struct {
@@ -30,14 +31,8 @@ func (cl *ContactListener) PreSolve(contact box2d.B2ContactInterface, oldManifol
 	bodyA := fixtureA.GetBody()
 	bodyB := fixtureB.GetBody()
 
-	var shapeA, shapeB *Shape
-	for _, obj := range cl.world.Objects {
-		if obj.Body == bodyA {
-			shapeA = obj
-		} else if obj.Body == bodyB {
-			shapeB = obj
-		}
-	}
+	shapeA := shapeOf(bodyA)
+	shapeB := shapeOf(bodyB)
 
 	if shapeA == nil || shapeB == nil {
 		return
@@ -53,14 +48,8 @@ func (cl *ContactListener) PostSolve(contact box2d.B2ContactInterface, impulse *
 	bodyA := contact.GetFixtureA().GetBody()
 	bodyB := contact.GetFixtureB().GetBody()
 
-	var shapeA, shapeB *Shape
-	for _, obj := range cl.world.Objects {
-		if obj.Body == bodyA {
-			shapeA = obj
-		} else if obj.Body == bodyB {
-			shapeB = obj
-		}
-	}
+	shapeA := shapeOf(bodyA)
+	shapeB := shapeOf(bodyB)
 
 	if shapeA == nil || shapeB == nil {
 		return
@@ -103,14 +92,8 @@ func (cl *ContactListener) BeginContact(contact box2d.B2ContactInterface) {
 	bodyA := fixtureA.GetBody()
 	bodyB := fixtureB.GetBody()
 
-	var shapeA, shapeB *Shape
-	for _, obj := range cl.world.Objects {
-		if obj.Body == bodyA {
-			shapeA = obj
-		} else if obj.Body == bodyB {
-			shapeB = obj
-		}
-	}
+	shapeA := shapeOf(bodyA)
+	shapeB := shapeOf(bodyB)
 
 	if shapeA == nil || shapeB == nil {
 		return
@@ -131,14 +114,8 @@ func (cl *ContactListener) EndContact(contact box2d.B2ContactInterface) {
 	bodyA := fixtureA.GetBody()
 	bodyB := fixtureB.GetBody()
 
-	var shapeA, shapeB *Shape
-	for _, obj := range cl.world.Objects {
-		if obj.Body == bodyA {
-			shapeA = obj
-		} else if obj.Body == bodyB {
-			shapeB = obj
-		}
-	}
+	shapeA := shapeOf(bodyA)
+	shapeB := shapeOf(bodyB)
 
 	if shapeA == nil || shapeB == nil {
 		return
@@ -180,33 +157,108 @@ type World struct {
 	Title      string
 	lastUpdate time.Time
 
-	Pattern    PatternType
-	Background color.Color
-	Border     *Border
-
-	Objects []*Shape
-	mutex   sync.RWMutex
+	// FixedStep is the simulated time, in seconds, advanced by every physics
+	// step. MaxSubSteps caps how many steps a single Update may run to catch
+	// up after a hitch, so a long stall slows the game down instead of
+	// spiralling.
+	FixedStep   float64
+	MaxSubSteps int
+	accumulator float64
+
+	// frame counts Updates, not fixed steps, so it is the same in every
+	// step an Update runs.
+	frame int64
+
+	// TimeScale speeds up or slows down the game clock that drives timers.
+	// It defaults to 1; 0 stops the clock.
+	TimeScale  float64
+	clock      float64
+	timers     []*Timer
+	timerCount int
+	timerStep  int
+	tweens     []*TweenHandle
+	emitters   []*ParticleEmitter
+
+	// PostProcess runs full-screen passes over every frame, after the world,
+	// the level's Render and the scenes are drawn. A level with its own
+	// PostProcess uses that one instead while it is mounted.
+	PostProcess *PostProcess
+
+	Pattern     PatternType
+	Background  color.Color
+	Backgrounds []BackgroundLayer
+	TileLayers  []*TileLayer
+	Border      *Border
+
+	// MergeTiles combines neighbouring static tiles of generated maps into
+	// fewer bodies. Merged tiles are still drawn but are no longer in Objects.
+	MergeTiles TileMerge
+
+	Objects       []*Shape
+	index         *shapeIndex
+	hoveredShapes []*Shape
+	mutex         sync.RWMutex
+
+	systems      []*System
+	systemCount  int
+	levelSystems []string
+
+	prefabs     map[string]*Prefab
+	prefabMutex sync.RWMutex
 
 	AudioManager *AudioManager
+	Camera       *Camera
 
+	// Mouse.X and Mouse.Y are in screen pixels, Mouse.WorldX and
+	// Mouse.WorldY are the same point seen through the Camera.
 	Mouse struct {
 		X, Y                          float64
+		WorldX, WorldY                float64
 		IsLeftClicked, IsRightClicked bool
 		IsMiddleClicked               bool
 	}
 	Keys      map[ebiten.Key]bool
 	keysMutex sync.RWMutex
 
+	inputChars []rune
+
+	Input    InputSource
+	recorder *replayRecorder
+
+	// Seed seeds Rand as well as the generator behind shape IDs and names, so
+	// a replay started with the same seed creates identical shapes.
+	Seed int64
+	Rand *rand.Rand
+
 	HasLimits bool
 	Paused    bool
 	Cursor    CursorType
 
+	// Headless worlds never touch the window or the audio device, and poll no
+	// input unless an Input source is given.
+	Headless bool
+
 	OnMouseDown func(x, y float64)
 	OnMouseUp   func(x, y float64)
 	OnMouseMove func(x, y float64)
 
 	Levels       []Level
 	CurrentLevel int
+	scenes       []*Scene
+
+	// Transition covers level switches requested through NextLevel and
+	// SwitchToLevel. LoadingScreen draws while a level's Preload runs.
+	Transition    Transition
+	LoadingScreen func(screen *ebiten.Image)
+	levelSwitch   *levelSwitch
+	levelLoaded   bool
+	levelSeed     int64
+
+	mapWatch      *mapWatch
+	loadedMap     *loadedMap
+	mapShapes     []*Shape
+	mapTiles      []*Shape
+	generatingMap bool
 
 	pendingLevelSwitch *int
 	collisionQueue     []CollisionEvent
@@ -230,8 +282,7 @@ func (w *World) SetTagCollisionFilter(tag string, collidesWith []string) {
 
 	for _, shape := range taggedShapes {
 		if shape.Body != nil {
-			fixture := shape.Body.GetFixtureList()
-			if fixture != nil {
+			for fixture := shape.Body.GetFixtureList(); fixture != nil; fixture = fixture.GetNext() {
 				filter := fixture.GetFilterData()
 				filter.GroupIndex = groupIndex
 				fixture.SetFilterData(filter)
@@ -257,8 +308,7 @@ func (w *World) setupCategoryMaskFiltering(tag string, collidesWith []string) {
 
 	for _, shape := range taggedShapes {
 		if shape.Body != nil {
-			fixture := shape.Body.GetFixtureList()
-			if fixture != nil {
+			for fixture := shape.Body.GetFixtureList(); fixture != nil; fixture = fixture.GetNext() {
 				filter := fixture.GetFilterData()
 				filter.CategoryBits = categoryBit
 				filter.MaskBits = maskBits
@@ -327,9 +377,19 @@ type WorldProps struct {
 	Title         string
 	AirResistance float64
 	AudioProps    *AudioProps
-
-	Levels       []Level
-	CurrentLevel int
+	FixedStep     float64
+	MaxSubSteps   int
+	TimeScale     float64
+	PostProcess   *PostProcess
+	Headless      bool
+	Input         InputSource
+	Seed          int64
+	MergeTiles    TileMerge
+
+	Levels        []Level
+	CurrentLevel  int
+	Transition    Transition
+	LoadingScreen func(screen *ebiten.Image)
 }
 
 func NewWorld(props *WorldProps) *World {
@@ -355,12 +415,32 @@ func NewWorld(props *WorldProps) *World {
 	if props.Title == "" {
 		props.Title = "Life Game"
 	}
+	if props.FixedStep <= 0 {
+		props.FixedStep = 1.0 / 60.0
+	}
+	if props.MaxSubSteps <= 0 {
+		props.MaxSubSteps = 5
+	}
+	if props.TimeScale == 0 {
+		props.TimeScale = 1
+	}
+	if props.Input == nil && !props.Headless {
+		props.Input = &EbitenInput{}
+	}
+	if props.Seed == 0 {
+		props.Seed = time.Now().UnixNano()
+	}
 
-	contactListener := ContactListener{}
+	var audioManager *AudioManager
+	if props.Headless {
+		audioManager = NewSilentAudioManager(props.AudioProps)
+	} else {
+		audioManager = NewAudioManager(props.AudioProps)
+	}
 
-	gravity := box2d.MakeB2Vec2(MetersToPixels(props.G.X), MetersToPixels(props.G.Y))
+	contactListener := ContactListener{}
 
-	physicsWorld := box2d.MakeB2World(gravity)
+	physicsWorld := box2d.MakeB2World(box2dGravity(props.G))
 	physicsWorld.SetAllowSleeping(true)
 
 	physicsWorld.SetContactListener(&contactListener)
@@ -378,16 +458,26 @@ func NewWorld(props *WorldProps) *World {
 		Border:             props.Border,
 		Paused:             props.Paused,
 		Cursor:             props.Cursor,
+		Headless:           props.Headless,
+		Input:              props.Input,
 		Keys:               make(map[ebiten.Key]bool),
 		lastUpdate:         time.Now(),
 		Title:              props.Title,
 		AirResistance:      props.AirResistance,
-		AudioManager:       NewAudioManager(props.AudioProps),
+		FixedStep:          props.FixedStep,
+		MaxSubSteps:        props.MaxSubSteps,
+		TimeScale:          props.TimeScale,
+		PostProcess:        props.PostProcess,
+		MergeTiles:         props.MergeTiles,
+		AudioManager:       audioManager,
 		Levels:             props.Levels,
+		Transition:         props.Transition,
+		LoadingScreen:      props.LoadingScreen,
 		CurrentLevel:       0,
 		pendingLevelSwitch: nil,
 		collisionQueue:     make([]CollisionEvent, 0),
 		drawCommands:       make([]DrawCommand, 0),
+		index:              newShapeIndex(),
 	}
 
 	if len(world.Levels) == 0 {
@@ -405,6 +495,9 @@ func NewWorld(props *WorldProps) *World {
 	}
 
 	contactListener.world = world
+	world.addBuiltinSystems()
+	world.Camera = NewCamera(world)
+	world.SetSeed(props.Seed)
 
 	if world.Render == nil {
 		world.Render = func(screen *ebiten.Image) {
@@ -433,6 +526,29 @@ func NewWorld(props *WorldProps) *World {
 	return world
 }
 
+func box2dGravity(g Vector2) box2d.B2Vec2 {
+	return box2d.MakeB2Vec2(MetersToPixels(g.X), MetersToPixels(g.Y))
+}
+
+// SetSeed reseeds the world's Rand and the package-wide ID generator.
+func (w *World) SetSeed(seed int64) {
+	w.Seed = seed
+	w.Rand = rand.New(rand.NewSource(seed))
+	SeedRandom(seed)
+}
+
+// NewHeadlessWorld creates a world that can be stepped without an ebiten
+// window or an audio device, e.g. from go test or a server process. Drive it
+// with SelectLevel and Advance.
+func NewHeadlessWorld(props *WorldProps) *World {
+	if props == nil {
+		props = &WorldProps{}
+	}
+
+	props.Headless = true
+	return NewWorld(props)
+}
+
 func (w *World) Pen(shapeType ShapeType, props *ShapeProps) {
 	w.drawMutex.Lock()
 	defer w.drawMutex.Unlock()
@@ -564,6 +680,7 @@ func (w *World) Destroy() {
 	}
 
 	w.Objects = nil
+	w.index.reset(nil)
 	w.contactListener.world = nil
 	w.contactListener = nil
 	w.PhysicsWorld = nil
@@ -589,18 +706,58 @@ func (w *World) SwitchToLevel(levelIndex int) {
 	w.pendingLevelSwitch = &levelIndex
 }
 
-func (w *World) SelectLevel(index int) {
+// SelectLevel switches to the level right away, without a transition, and
+// loads it synchronously.
+func (w *World) SelectLevel(index int) error {
+	return w.selectLevel(index, w.Rand.Int63())
+}
+
+func (w *World) selectLevel(index int, seed int64) error {
 	if index < 0 || index >= len(w.Levels) {
-		return
+		return fmt.Errorf("level %d does not exist", index)
 	}
 
+	w.levelSwitch = nil
+	w.unloadLevel()
 	w.CurrentLevel = index
+
 	level := w.Levels[index]
+	if level.Preload != nil {
+		if err := level.Preload(w); err != nil {
+			return fmt.Errorf("failed to preload level %d: %w", index, err)
+		}
+	}
 
-	if level.OnDestroy != nil {
+	loaded, err := w.loadLevelMap(level)
+	if err != nil {
+		return fmt.Errorf("failed to load level %d: %w", index, err)
+	}
+	w.loadedMap = loaded
 
-		level.OnDestroy(w)
+	w.mountLevel(index, seed)
+	return nil
+}
+
+// unloadLevel tears down the mounted level and the scenes above it that are
+// not Persistent, calling their OnDestroy first.
+func (w *World) unloadLevel() {
+	w.clearLevelScenes()
+
+	if w.levelLoaded && w.CurrentLevel < len(w.Levels) {
+		outgoing := w.Levels[w.CurrentLevel]
+		if outgoing.OnDestroy != nil {
+			outgoing.OnDestroy(w)
+		}
 	}
+	w.levelLoaded = false
+	w.unmountSystems()
+	w.clearTimers()
+	w.clearTweens()
+	w.emitters = nil
+	w.hoveredShapes = nil
+	w.mapShapes = nil
+	w.mapTiles = nil
+	w.TileLayers = nil
 
 	w.mutex.Lock()
 
@@ -609,9 +766,21 @@ func (w *World) SelectLevel(index int) {
 			w.PhysicsWorld.DestroyBody(obj.Body)
 			obj.Body = nil
 		}
+		obj.world = nil
 	}
 	w.Objects = make([]*Shape, 0)
+	w.index.reset(nil)
 	w.mutex.Unlock()
+}
+
+// mountLevel runs Init, builds the map and calls OnMount. Preload must have
+// finished already. The ID generator is reseeded with seed first, so mounting
+// the same level with the same seed gives its shapes the same IDs.
+func (w *World) mountLevel(index int, seed int64) {
+	w.CurrentLevel = index
+	w.levelSeed = seed
+	SeedRandom(seed)
+	level := w.Levels[index]
 
 	if level.Tick != nil {
 		w.Tick = level.Tick
@@ -625,16 +794,24 @@ func (w *World) SelectLevel(index int) {
 		w.Render = func(screen *ebiten.Image) {}
 	}
 
+	w.Backgrounds = append([]BackgroundLayer(nil), level.Backgrounds...)
+	w.mountSystems(level)
+
 	if level.Init != nil {
 		level.Init(w)
 	}
 
-	w.GenerateLevelFromMap(level.Map, level.MapItems)
+	if w.loadedMap != nil {
+		w.generateLoadedMap(level, w.loadedMap)
+	} else {
+		w.GenerateLevelFromMap(level.Map, level.MapItems)
+	}
 
 	if level.OnMount != nil {
 		level.OnMount()
 	}
 
+	w.levelLoaded = true
 }
 
 func (w *World) CreateBorders() {
@@ -710,7 +887,16 @@ func (w *World) Register(object *Shape) {
 
 	object.world = w
 	w.Objects = append(w.Objects, object)
+	w.index.add(object)
 	w.createPhysicsBody(object)
+	object.storePreviousState()
+
+	w.tweens = append(w.tweens, object.pendingTweens...)
+	object.pendingTweens = nil
+
+	if w.generatingMap {
+		w.mapShapes = append(w.mapShapes, object)
+	}
 }
 
 func (w *World) Unregister(object *Shape) {
@@ -721,8 +907,13 @@ func (w *World) Unregister(object *Shape) {
 		if obj.ID == object.ID {
 			if obj.Body != nil {
 				w.PhysicsWorld.DestroyBody(obj.Body)
+				obj.Body = nil
 			}
 			w.Objects = append(w.Objects[:i], w.Objects[i+1:]...)
+			w.index.remove(obj)
+			// A removed shape no longer belongs to the world, so SetTag
+			// and SetName leave the index alone.
+			obj.world = nil
 			break
 		}
 	}
@@ -749,24 +940,35 @@ func (w *World) createPhysicsBody(object *Shape) {
 	bodyDef.Position.Set(PixelsToMeters(centerX), PixelsToMeters(centerY))
 
 	body := w.PhysicsWorld.CreateBody(&bodyDef)
+	body.SetUserData(object)
 
 	body.SetMassData(&box2d.B2MassData{
 		Mass: object.Mass,
 	})
 
-	var shape box2d.B2ShapeInterface
-	switch object.Type {
-	case ShapeCircle:
+	var shapes []box2d.B2ShapeInterface
+	switch {
+	case len(object.chains) > 0:
+		for _, loop := range object.chains {
+			vertices := make([]box2d.B2Vec2, len(loop))
+			for i, p := range loop {
+				vertices[i] = box2d.MakeB2Vec2(PixelsToMeters(p.X-object.Width/2), PixelsToMeters(p.Y-object.Height/2))
+			}
+			chainShape := box2d.MakeB2ChainShape()
+			chainShape.CreateLoop(vertices, len(vertices))
+			shapes = append(shapes, &chainShape)
+		}
+	case object.Type == ShapeCircle:
 		circleShape := box2d.MakeB2CircleShape()
 		circleShape.SetRadius(PixelsToMeters(object.Radius))
-		shape = &circleShape
+		shapes = append(shapes, &circleShape)
 	default:
 		boxShape := box2d.MakeB2PolygonShape()
 		if object.Width <= 0 || object.Height <= 0 {
 			panic("Width and Height must be greater than 0 for rectangle shapes")
 		}
 		boxShape.SetAsBox(PixelsToMeters(object.Width/2), PixelsToMeters(object.Height/2))
-		shape = &boxShape
+		shapes = append(shapes, &boxShape)
 	}
 
 	density := 0.0
@@ -774,21 +976,23 @@ func (w *World) createPhysicsBody(object *Shape) {
 		density = 0.0
 	}
 
-	fixture := body.CreateFixture(shape, density)
+	for _, shape := range shapes {
+		fixture := body.CreateFixture(shape, density)
 
-	if object.Ghost {
-		fixture.SetSensor(true)
-	}
+		if object.Ghost {
+			fixture.SetSensor(true)
+		}
 
-	fixture.SetFriction(object.Friction)
-	fixture.SetRestitution(object.Rebound)
+		fixture.SetFriction(object.Friction)
+		fixture.SetRestitution(object.Rebound)
 
-	filter := fixture.GetFilterData()
-	filter.CategoryBits = 1
-	filter.MaskBits = 0xFFFF
-	filter.GroupIndex = 0
+		filter := fixture.GetFilterData()
+		filter.CategoryBits = 1
+		filter.MaskBits = 0xFFFF
+		filter.GroupIndex = 0
 
-	fixture.SetFilterData(filter)
+		fixture.SetFilterData(filter)
+	}
 
 	object.Body = body
 }
@@ -798,15 +1002,30 @@ func (w *World) GenerateLevelFromMap(levelMap Map, objects map[string]func(posit
 		return
 	}
 
+	// Shapes registered while the map is generated are remembered, so a hot
+	// reload can remove exactly those.
+	w.generatingMap = true
+	defer func() { w.generatingMap = false }()
+
 	rows := len(levelMap)
 	cols := len(levelMap[0])
 	tileWidth := float64(w.Width) / float64(cols)
 	tileHeight := float64(w.Height) / float64(rows)
 
-	const overlap = 0.5
+	// Tiles overlap slightly so bodies do not catch on the seams between
+	// them. Merged tiles have no seams, so they are laid out exactly.
+	overlap := 0.5
+	var grid *tileGrid
+	if w.MergeTiles != TileMergeNone {
+		overlap = 0
+		for _, row := range levelMap {
+			cols = max(cols, len([]rune(row)))
+		}
+		grid = newTileGrid(cols, rows)
+	}
 
 	for y, row := range levelMap {
-		for x, ch := range row {
+		for x, ch := range []rune(row) {
 			fn, ok := objects[string(ch)]
 			if !ok {
 				continue
@@ -816,40 +1035,86 @@ func (w *World) GenerateLevelFromMap(levelMap Map, objects map[string]func(posit
 				X: float64(x)*tileWidth - overlap/2,
 				Y: float64(y)*tileHeight - overlap/2,
 			}
+
+			registered := len(w.mapShapes)
 			fn(pos, tileWidth+overlap, tileHeight+overlap)
+
+			if grid != nil && len(w.mapShapes) == registered+1 {
+				grid.set(x, y, string(ch), w.mapShapes[registered])
+			}
 		}
 	}
+
+	if grid != nil {
+		w.mergeTiles(grid)
+	}
 }
 
 func (w *World) Update() error {
+	now := time.Now()
+
 	if w.Paused {
+		w.lastUpdate = now
 		return nil
 	}
 
-	now := time.Now()
-	var deltaTime float64
+	w.pollMapFile()
+	w.frame++
+
+	var frameTime float64
 	if !w.lastUpdate.IsZero() {
-		deltaTime = now.Sub(w.lastUpdate).Seconds()
+		frameTime = now.Sub(w.lastUpdate).Seconds()
 	} else {
-		deltaTime = 1.0 / 60.0
+		frameTime = w.FixedStep
 	}
 	w.lastUpdate = now
 
-	velocityIterations := 6
-	positionIterations := 3
-	w.PhysicsWorld.Step(deltaTime, velocityIterations, positionIterations)
+	maxFrameTime := w.FixedStep * float64(w.MaxSubSteps)
+	if frameTime > maxFrameTime {
+		frameTime = maxFrameTime
+	}
+	w.accumulator += frameTime
 
 	if w.AudioManager != nil {
 		w.AudioManager.Update()
 	}
 
-	w.processCollisions()
+	for steps := 0; w.accumulator >= w.FixedStep && steps < w.MaxSubSteps; steps++ {
+		w.accumulator -= w.FixedStep
 
-	if w.pendingLevelSwitch != nil {
-		levelIndex := *w.pendingLevelSwitch
-		w.pendingLevelSwitch = nil
-		w.SelectLevel(levelIndex)
-		return nil
+		mounted, err := w.step(now)
+		if err != nil {
+			return err
+		}
+		if mounted {
+			w.accumulator = 0
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// step advances the simulation by exactly one FixedStep. It reports whether a
+// new level was mounted, in which case the caller should stop stepping for
+// this frame.
+func (w *World) step(now time.Time) (bool, error) {
+	// Nothing is simulated while a level loads, so input is not polled and
+	// replays stay in sync however long loading takes.
+	if w.isLoadingLevel() {
+		return w.updateLevelSwitch()
+	}
+
+	w.updateInput()
+
+	ld := LoopData{
+		Time:  now,
+		Frame: w.frame,
+		Delta: w.FixedStep,
+	}
+	scenes, levelTicks := w.tickingScenes()
+	if w.levelSwitch != nil {
+		levelTicks = false
 	}
 
 	w.mutex.RLock()
@@ -858,49 +1123,176 @@ func (w *World) Update() error {
 	w.mutex.RUnlock()
 
 	for _, obj := range objects {
-		obj.Update()
+		obj.storePreviousState()
 	}
 
-	if w.Tick != nil {
-		w.Tick(LoopData{
-			Time:  now,
-			Delta: deltaTime,
-		})
+	if levelTicks {
+		velocityIterations := 6
+		positionIterations := 3
+		w.PhysicsWorld.Step(w.FixedStep, velocityIterations, positionIterations)
+
+		w.processCollisions()
 	}
 
-	w.updateInput()
+	// A switch requested during a transition waits for it to finish; only
+	// the last request is kept.
+	if w.pendingLevelSwitch != nil && w.levelSwitch == nil {
+		levelIndex := *w.pendingLevelSwitch
+		w.pendingLevelSwitch = nil
+
+		w.beginLevelSwitch(levelIndex)
+		levelTicks = false
+	}
+
+	if levelTicks {
+		for _, obj := range objects {
+			obj.Update()
+		}
+
+		w.updateTimers(w.FixedStep * w.TimeScale)
+		w.updateTweens(w.FixedStep * w.TimeScale)
+		w.updateEmitters(w.FixedStep * w.TimeScale)
+		w.runSystems(ld)
+
+		if w.Tick != nil {
+			w.Tick(ld)
+		}
+
+		w.updateBackgrounds(w.FixedStep)
+	}
+
+	for _, scene := range scenes {
+		if scene.Tick != nil {
+			scene.Tick(ld)
+		}
+	}
+
+	if w.Camera != nil {
+		w.Camera.update(w.FixedStep)
+	}
+
+	if w.levelSwitch != nil && !w.isLoadingLevel() {
+		return w.updateLevelSwitch()
+	}
+	return false, nil
+}
+
+// Advance runs the given number of fixed steps right away instead of waiting
+// on the wall clock, which is how headless worlds are driven.
+func (w *World) Advance(frames int) error {
+	w.accumulator = 0
+
+	for i := 0; i < frames; i++ {
+		if w.Paused {
+			return nil
+		}
+
+		w.frame++
+		if _, err := w.step(time.Now()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// interpolationAlpha is how far, between 0 and 1, the renderer is between the
+// last two physics steps.
+func (w *World) interpolationAlpha() float64 {
+	if w.FixedStep <= 0 {
+		return 1
+	}
+
+	alpha := w.accumulator / w.FixedStep
+	if alpha > 1 {
+		alpha = 1
+	}
+	return alpha
+}
+
+// updateInput polls the world's InputSource once per step. Mouse down/up
+// edges are derived from the polled state rather than from ebiten, so
+// recorded and replayed input produce the same events.
 func (w *World) updateInput() {
-	x, y := ebiten.CursorPosition()
-	w.Mouse.X = float64(x)
-	w.Mouse.Y = float64(y)
+	if w.Input == nil {
+		return
+	}
+
+	state := w.Input.Poll()
+	if w.recorder != nil {
+		w.recorder.record(state)
+	}
 
-	w.Mouse.IsLeftClicked = ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
-	w.Mouse.IsRightClicked = ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
-	w.Mouse.IsMiddleClicked = ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle)
+	wasLeftClicked := w.Mouse.IsLeftClicked
+	moved := state.MouseX != w.Mouse.X || state.MouseY != w.Mouse.Y
+
+	w.Mouse.X = state.MouseX
+	w.Mouse.Y = state.MouseY
+	w.Mouse.WorldX, w.Mouse.WorldY = w.ScreenToWorld(state.MouseX, state.MouseY)
+	w.Mouse.IsLeftClicked = state.IsLeftClicked
+	w.Mouse.IsRightClicked = state.IsRightClicked
+	w.Mouse.IsMiddleClicked = state.IsMiddleClicked
 
 	w.keysMutex.Lock()
-	for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
-		w.Keys[key] = ebiten.IsKeyPressed(key)
+	for key := range w.Keys {
+		w.Keys[key] = false
+	}
+	for _, key := range state.Keys {
+		w.Keys[key] = true
 	}
 	w.keysMutex.Unlock()
+	w.inputChars = state.Chars
+
+	w.updateHover(moved)
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+	if state.IsLeftClicked && !wasLeftClicked {
 		w.handleMouseDown(w.Mouse.X, w.Mouse.Y)
 	}
-	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+	if !state.IsLeftClicked && wasLeftClicked {
 		w.handleMouseUp(w.Mouse.X, w.Mouse.Y)
 	}
 }
 
+// updateHover emits EventMouseEnter and EventHover on the shapes the cursor
+// moved onto, EventMouseLeave and EventUnHover on those it left, and
+// EventMouseMove on those under a moving cursor.
+func (w *World) updateHover(moved bool) {
+	var hovered []*Shape
+	if _, levelTicks := w.tickingScenes(); levelTicks {
+		hovered = w.HoveredObjects()
+	}
+
+	current := make(map[*Shape]bool, len(hovered))
+	for _, obj := range hovered {
+		current[obj] = true
+		if !obj.Hovered {
+			obj.Hovered = true
+			obj.Emit(EventMouseEnter, EventMouseEnterData{Shape: obj})
+			obj.Emit(EventHover, w.mouseEventData())
+		} else if moved {
+			obj.Emit(EventMouseMove, w.mouseEventData())
+		}
+	}
+
+	for _, obj := range w.hoveredShapes {
+		if !current[obj] && obj.Hovered {
+			obj.Hovered = false
+			obj.Emit(EventMouseLeave, EventMouseEnterData{Shape: obj})
+			obj.Emit(EventUnHover, w.mouseEventData())
+		}
+	}
+	w.hoveredShapes = hovered
+}
+
 func (w *World) handleMouseDown(x, y float64) {
-	hoveredObjects := w.HoveredObjects()
+	var hoveredObjects []*Shape
+	if _, levelTicks := w.tickingScenes(); levelTicks {
+		hoveredObjects = w.HoveredObjects()
+	}
+
 	for _, obj := range hoveredObjects {
 		if !obj.Clicked {
 			obj.Clicked = true
-			obj.Emit(EventMouseDown, Vector2{X: x, Y: y})
+			obj.Emit(EventMouseDown, w.mouseEventData())
 		}
 	}
 
@@ -910,10 +1302,14 @@ func (w *World) handleMouseDown(x, y float64) {
 }
 
 func (w *World) handleMouseUp(x, y float64) {
-	hoveredObjects := w.HoveredObjects()
+	var hoveredObjects []*Shape
+	if _, levelTicks := w.tickingScenes(); levelTicks {
+		hoveredObjects = w.HoveredObjects()
+	}
+
 	for _, obj := range hoveredObjects {
-		obj.Emit(EventMouseUp, Vector2{X: x, Y: y})
-		obj.Emit(EventClick, Vector2{X: x, Y: y})
+		obj.Emit(EventMouseUp, w.mouseEventData())
+		obj.Emit(EventClick, w.mouseEventData())
 		if obj.Clicked {
 			obj.Clicked = false
 		}
@@ -924,6 +1320,12 @@ func (w *World) handleMouseUp(x, y float64) {
 	}
 }
 
+// mouseEventData is the data of mouse events: the cursor in screen pixels.
+// Handlers get the point in the world from GetCursorWorldPosition.
+func (w *World) mouseEventData() Vector2 {
+	return Vector2{X: w.Mouse.X, Y: w.Mouse.Y}
+}
+
 func (w *World) Draw(screen *ebiten.Image) {
 	if w.Screen != screen {
 		w.Screen = screen
@@ -932,8 +1334,9 @@ func (w *World) Draw(screen *ebiten.Image) {
 	screen.Fill(w.Background)
 
 	w.mutex.RLock()
-	objects := make([]*Shape, len(w.Objects))
+	objects := make([]*Shape, len(w.Objects), len(w.Objects)+len(w.mapTiles))
 	copy(objects, w.Objects)
+	objects = append(objects, w.mapTiles...)
 	w.mutex.RUnlock()
 
 	w.drawMutex.Lock()
@@ -942,6 +1345,12 @@ func (w *World) Draw(screen *ebiten.Image) {
 	w.drawCommands = w.drawCommands[:0]
 	w.drawMutex.Unlock()
 
+	if _, levelRenders := w.renderingScenes(); !levelRenders {
+		return
+	}
+
+	w.drawBackgrounds(screen)
+
 	var tempShapes []*Shape
 	for _, cmd := range drawCommands {
 		tempShape := NewShape(cmd.Props)
@@ -949,7 +1358,15 @@ func (w *World) Draw(screen *ebiten.Image) {
 		tempShapes = append(tempShapes, tempShape)
 	}
 
-	allShapes := append(objects, tempShapes...)
+	// Only shapes that can reach the screen are sorted and drawn, so maps
+	// with thousands of tiles cost little beyond the visible part.
+	visible := w.visibleWorldRect(screen)
+	allShapes := make([]*Shape, 0, len(objects)+len(tempShapes))
+	for _, obj := range append(objects, tempShapes...) {
+		if obj.isVisible(visible) {
+			allShapes = append(allShapes, obj)
+		}
+	}
 
 	sort.Slice(allShapes, func(i, j int) bool {
 		if allShapes[i].Tag == "border" && allShapes[j].Tag != "border" {
@@ -961,8 +1378,27 @@ func (w *World) Draw(screen *ebiten.Image) {
 		return allShapes[i].ZIndex < allShapes[j].ZIndex
 	})
 
+	view := w.View()
+	tileLayers := w.sortedTileLayers()
+	emitters := w.sortedEmitters()
+
+	// Particles are drawn over the shapes of their own ZIndex.
 	for _, obj := range allShapes {
-		obj.Draw(screen)
+		for len(tileLayers) > 0 && obj.Tag != "border" && tileLayers[0].ZIndex <= obj.ZIndex {
+			tileLayers[0].Draw(screen, view, visible)
+			tileLayers = tileLayers[1:]
+		}
+		for len(emitters) > 0 && obj.Tag != "border" && emitters[0].ZIndex < obj.ZIndex {
+			emitters[0].Draw(screen, view)
+			emitters = emitters[1:]
+		}
+		obj.DrawTransformed(screen, view)
+	}
+	for _, layer := range tileLayers {
+		layer.Draw(screen, view, visible)
+	}
+	for _, emitter := range emitters {
+		emitter.Draw(screen, view)
 	}
 }
 
@@ -999,8 +1435,7 @@ func (w *World) ResumeMusic() {
 }
 
 func (w *World) Center(obj *Shape, resetVelocity bool) {
-	obj.SetX(float64(w.Width)/2 - obj.Width/2)
-	obj.SetY(float64(w.Height)/2 - obj.Height/2)
+	obj.SetPosition(float64(w.Width)/2-obj.Width/2, float64(w.Height)/2-obj.Height/2)
 
 	if resetVelocity {
 		obj.SetVelocity(0, 0)
@@ -1029,13 +1464,15 @@ func (w *World) HoveredObjects() []*Shape {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
+	cursor := Vector2{X: w.Mouse.WorldX, Y: w.Mouse.WorldY}
+
 	var hovered []*Shape
-	for _, obj := range w.Objects {
-		if w.Mouse.X >= obj.X && w.Mouse.X <= obj.X+obj.Width &&
-			w.Mouse.Y >= obj.Y && w.Mouse.Y <= obj.Y+obj.Height {
+	w.queryRegion(Rect{X: cursor.X, Y: cursor.Y}, func(obj *Shape) bool {
+		if obj.Bounds().Contains(cursor) {
 			hovered = append(hovered, obj)
 		}
-	}
+		return true
+	})
 	return hovered
 }
 
@@ -1045,8 +1482,8 @@ func (w *World) UnhoveredObjects() []*Shape {
 
 	var unhovered []*Shape
 	for _, obj := range w.Objects {
-		if !(w.Mouse.X >= obj.X && w.Mouse.X <= obj.X+obj.Width &&
-			w.Mouse.Y >= obj.Y && w.Mouse.Y <= obj.Y+obj.Height) {
+		if !(w.Mouse.WorldX >= obj.X && w.Mouse.WorldX <= obj.X+obj.Width &&
+			w.Mouse.WorldY >= obj.Y && w.Mouse.WorldY <= obj.Y+obj.Height) {
 			unhovered = append(unhovered, obj)
 		}
 	}
@@ -1066,23 +1503,15 @@ func (w *World) GetElementsByTagName(tag string) []*Shape {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
-	var result []*Shape
-	for _, obj := range w.Objects {
-		if obj.Tag == tag {
-			result = append(result, obj)
-		}
-	}
-	return result
+	return append([]*Shape(nil), w.index.byTag[tag]...)
 }
 
 func (w *World) GetElementByName(name string) *Shape {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
-	for _, obj := range w.Objects {
-		if obj.Name == name {
-			return obj
-		}
+	if named := w.index.byName[name]; len(named) > 0 {
+		return named[0]
 	}
 	return nil
 }
@@ -1091,13 +1520,7 @@ func (w *World) GetElementsByName(name string) []*Shape {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
-	var result []*Shape
-	for _, obj := range w.Objects {
-		if obj.Name == name {
-			result = append(result, obj)
-		}
-	}
-	return result
+	return append([]*Shape(nil), w.index.byName[name]...)
 }
 
 func (w *World) GetElementsByType(shapeType ShapeType) []*Shape {
@@ -1125,12 +1548,42 @@ func (w *World) GetCursorPosition() Vector2 {
 	return Vector2{X: w.Mouse.X, Y: w.Mouse.Y}
 }
 
+func (w *World) GetCursorWorldPosition() Vector2 {
+	return Vector2{X: w.Mouse.WorldX, Y: w.Mouse.WorldY}
+}
+
+// View is the camera's world-to-screen transform, or the identity when the
+// world has no camera.
+func (w *World) View() ebiten.GeoM {
+	if w.Camera == nil {
+		return ebiten.GeoM{}
+	}
+	return w.Camera.View()
+}
+
+func (w *World) WorldToScreen(x, y float64) (float64, float64) {
+	m := w.View()
+	return m.Apply(x, y)
+}
+
+func (w *World) ScreenToWorld(x, y float64) (float64, float64) {
+	m := w.View()
+	m.Invert()
+	return m.Apply(x, y)
+}
+
 func (w *World) IsKeyPressed(key ebiten.Key) bool {
 	w.keysMutex.RLock()
 	defer w.keysMutex.RUnlock()
 	return w.Keys[key]
 }
 
+// InputChars returns the characters typed since the last step, for text
+// entry.
+func (w *World) InputChars() []rune {
+	return w.inputChars
+}
+
 func (w *World) OncePressed(key ebiten.Key, callback func()) {
 
 }
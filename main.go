@@ -4,9 +4,18 @@ import (
 	"boughtnine/entities"
 	"boughtnine/levels"
 	"boughtnine/life"
+	"flag"
+	"log"
+	"os"
+	"time"
 )
 
 func main() {
+	record := flag.String("record", "", "record input to the given replay file")
+	replay := flag.String("replay", "", "play back the given replay file")
+	dev := flag.Bool("dev", false, "load level maps from ./levels and reload them when they change")
+	flag.Parse()
+
 	world := entities.NewWorld()
 	game := life.NewGame(world)
 
@@ -15,5 +24,27 @@ func main() {
 		levels.Two,
 	}
 
+	if *dev {
+		world.WatchMaps(os.DirFS("levels"), time.Second)
+	}
+
+	if *replay != "" {
+		r, err := life.LoadReplay(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := world.PlayReplay(r); err != nil {
+			log.Fatal(err)
+		}
+	} else if *record != "" {
+		world.StartRecording()
+	}
+
 	game.Run()
+
+	if *record != "" && *replay == "" {
+		if err := life.SaveReplay(*record, world.StopRecording()); err != nil {
+			log.Fatal(err)
+		}
+	}
 }